@@ -0,0 +1,359 @@
+package robodiff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Containers a ResultSource can be stored in.
+const (
+	ArchiveGzip  = "gzip"
+	ArchiveZip   = "zip"
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar.gz"
+)
+
+// MemberSeparator joins an archive path and the member inside it, e.g.
+// "results.zip!/run1/output.xml".
+const MemberSeparator = "!/"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// ResultSource is one result file: a plain or gzip-compressed file on disk,
+// or a member of a zip or tar archive.
+type ResultSource struct {
+	// Path is the file on disk.
+	Path string
+	// Member is the slash-separated path inside a zip or tar archive.
+	Member string
+	// Archive is "" for plain files, otherwise one of the Archive* kinds.
+	Archive string
+	// Format is the detected result format (see Format*).
+	Format string
+}
+
+// Seekable reports whether the content can be read at arbitrary offsets,
+// which is what Test.Offset/Length lookups need.
+func (s ResultSource) Seekable() bool {
+	return s.Archive == ""
+}
+
+func (s ResultSource) String() string {
+	if s.Member == "" {
+		return s.Path
+	}
+	return s.Path + MemberSeparator + s.Member
+}
+
+// Open returns the decompressed content of the source.
+func (s ResultSource) Open() (io.ReadCloser, error) {
+	switch s.Archive {
+	case "":
+		return os.Open(s.Path)
+	case ArchiveGzip:
+		f, err := os.Open(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &multiReadCloser{Reader: gz, closers: []io.Closer{gz, f}}, nil
+	case ArchiveZip:
+		zr, err := zip.OpenReader(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			if cleanMemberName(zf.Name) != s.Member {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				zr.Close()
+				return nil, err
+			}
+			return &multiReadCloser{Reader: rc, closers: []io.Closer{rc, zr}}, nil
+		}
+		zr.Close()
+		return nil, fmt.Errorf("%s: %w", s, os.ErrNotExist)
+	case ArchiveTar, ArchiveTarGz:
+		f, err := os.Open(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		closers := []io.Closer{f}
+		var r io.Reader = f
+		if s.Archive == ArchiveTarGz {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+			closers = append([]io.Closer{gz}, closers...)
+			r = gz
+		}
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				closeAll(closers)
+				if err == io.EOF {
+					return nil, fmt.Errorf("%s: %w", s, os.ErrNotExist)
+				}
+				return nil, err
+			}
+			if hdr.Typeflag == tar.TypeReg && cleanMemberName(hdr.Name) == s.Member {
+				return &multiReadCloser{Reader: tr, closers: closers}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown archive kind %q", s.Archive)
+}
+
+// OpenSibling opens rel relative to the directory of the source, inside the
+// archive for zip/tar members and on disk otherwise. It is used for
+// screenshots referenced from log messages.
+func (s ResultSource) OpenSibling(rel string) (io.ReadCloser, error) {
+	rel = path.Clean(filepath.ToSlash(rel))
+	if rel == "." || path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return nil, errors.New("invalid sibling path")
+	}
+	switch s.Archive {
+	case ArchiveZip, ArchiveTar, ArchiveTarGz:
+		member := path.Join(path.Dir(s.Member), rel)
+		return ResultSource{Path: s.Path, Member: member, Archive: s.Archive}.Open()
+	default:
+		return os.Open(filepath.Join(filepath.Dir(s.Path), filepath.FromSlash(rel)))
+	}
+}
+
+type multiReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiReadCloser) Close() error {
+	return closeAll(m.closers)
+}
+
+func closeAll(closers []io.Closer) error {
+	var first error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func cleanMemberName(name string) string {
+	return strings.TrimPrefix(path.Clean(strings.TrimPrefix(name, "./")), "/")
+}
+
+// IsArchiveFileName reports whether name looks like a compressed file or an
+// archive that DetectResultSources should look into.
+func IsArchiveFileName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".gz", ".tgz", ".zip", ".tar"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectResultSources lists the results stored at path: the file itself, the
+// content of a gzip file, or every result file inside a zip or tar(.gz)
+// archive. It returns nil when path holds no result we can read.
+func DetectResultSources(path string) []ResultSource {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil
+		}
+		defer gz.Close()
+		br := bufio.NewReaderSize(gz, formatProbeBytes)
+		inner, _ := br.Peek(formatProbeBytes)
+		if isTarHead(inner) {
+			return listTarSources(path, ArchiveTarGz, tar.NewReader(br))
+		}
+		if format := DetectFormat(inner); format != "" {
+			return []ResultSource{{Path: path, Archive: ArchiveGzip, Format: format}}
+		}
+		return nil
+	case bytes.HasPrefix(head, zipMagic):
+		return listZipSources(path)
+	case isTarHead(head):
+		return listTarSources(path, ArchiveTar, tar.NewReader(f))
+	}
+	if format := detectOpenFile(f); format != "" {
+		return []ResultSource{{Path: path, Format: format}}
+	}
+	return nil
+}
+
+func isTarHead(head []byte) bool {
+	return len(head) >= 262 && string(head[257:262]) == "ustar"
+}
+
+func listZipSources(path string) []ResultSource {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+
+	var sources []ResultSource
+	for _, zf := range zr.File {
+		name := cleanMemberName(zf.Name)
+		if zf.FileInfo().IsDir() || !isResultMemberName(name) {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			continue
+		}
+		format := detectReader(rc)
+		rc.Close()
+		if format != "" {
+			sources = append(sources, ResultSource{Path: path, Member: name, Archive: ArchiveZip, Format: format})
+		}
+	}
+	return sources
+}
+
+func listTarSources(path, kind string, tr *tar.Reader) []ResultSource {
+	var sources []ResultSource
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			return sources
+		}
+		name := cleanMemberName(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !isResultMemberName(name) {
+			continue
+		}
+		if format := detectReader(tr); format != "" {
+			sources = append(sources, ResultSource{Path: path, Member: name, Archive: kind, Format: format})
+		}
+	}
+}
+
+// isResultMemberName skips metadata that archivers add next to real files.
+func isResultMemberName(name string) bool {
+	if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") {
+		return false
+	}
+	return IsResultFileName(name)
+}
+
+func detectReader(r io.Reader) string {
+	buf := make([]byte, formatProbeBytes)
+	n, _ := io.ReadFull(r, buf)
+	return DetectFormat(buf[:n])
+}
+
+// ParseResultSourceWithOptions parses src, decompressing on the fly. Plain
+// files go through ParseResultFileWithOptions so their test offsets can be
+// used for seeking later.
+func ParseResultSourceWithOptions(ctx context.Context, src ResultSource, opts ParseOptions) (*Robot, error) {
+	if src.Seekable() {
+		return ParseResultFileWithOptions(ctx, src.Path, opts)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rc, err := src.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ParseResultReaderWithOptions(ctx, rc, opts)
+}
+
+// FindTestInSourceContext is FindTestInFileContext for any ResultSource.
+func FindTestInSourceContext(ctx context.Context, src ResultSource, name string) (*Test, error) {
+	if src.Seekable() {
+		return FindTestInFileContext(ctx, src.Path, name)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rc, err := src.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	br := bufio.NewReaderSize(rc, streamBufferSize)
+	head, _ := br.Peek(formatProbeBytes)
+	return findTestInReader(ctx, br, DetectFormat(head), name)
+}
+
+// SplitMemberPath splits "archive.zip!/dir/output.xml" into the archive path
+// and the member. Paths without a separator are returned unchanged.
+func SplitMemberPath(p string) (file, member string) {
+	if i := strings.Index(p, MemberSeparator); i >= 0 {
+		return p[:i], cleanMemberName(p[i+len(MemberSeparator):])
+	}
+	return p, ""
+}
+
+// ResolveResultSource picks the result at p, which may name an archive
+// member as "archive.zip!/dir/output.xml". An archive holding several results
+// must be given with a member.
+func ResolveResultSource(p string) (ResultSource, error) {
+	file, member := SplitMemberPath(p)
+	sources := DetectResultSources(file)
+	if member != "" {
+		for _, src := range sources {
+			if src.Member == member {
+				return src, nil
+			}
+		}
+		return ResultSource{}, fmt.Errorf("%s: no result named %s", file, member)
+	}
+	switch len(sources) {
+	case 0:
+		// Let the XML parser explain what is wrong with the file.
+		return ResultSource{Path: file}, nil
+	case 1:
+		return sources[0], nil
+	}
+	names := make([]string, len(sources))
+	for i, src := range sources {
+		names[i] = src.Member
+	}
+	return ResultSource{}, fmt.Errorf("%s holds %d results (%s); pick one as %s%s<member>",
+		file, len(sources), strings.Join(names, ", "), file, MemberSeparator)
+}
@@ -5,22 +5,50 @@ import (
 	"strings"
 )
 
-// DiffResults manages the comparison results.
+// DiffResults manages the comparison results. Rows are keyed by the
+// lower-cased path of suite and test names (see pathKey); the path with its
+// original casing is kept in identities.
 type DiffResults struct {
 	stats       map[string][]*ItemStatus
 	columnNames []string
+	// errors holds the execution errors of each column.
+	errors [][]Message
+	// tags holds the per-tag statistics of each column.
+	tags [][]Stat
+	// identities and renames match renamed tests (see identity.go).
+	identities map[string]rowIdentity
+	renames    map[string]rowRename
+	matching   MatchOptions
 }
 
 func NewDiffResults() *DiffResults {
+	return NewDiffResultsWithOptions(MatchOptions{})
+}
+
+// NewDiffResultsWithOptions is NewDiffResults with options for matching
+// renamed tests.
+func NewDiffResultsWithOptions(opts MatchOptions) *DiffResults {
 	return &DiffResults{
 		stats:       make(map[string][]*ItemStatus, 128),
 		columnNames: make([]string, 0, 4),
+		identities:  make(map[string]rowIdentity, 128),
+		renames:     make(map[string]rowRename),
+		matching:    opts,
 	}
 }
 
 func (dr *DiffResults) AddParsedOutput(robot *Robot, column string) {
-	dr.addSuite(&robot.Suite, "")
+	entries := collectEntries(&robot.Suite, nil, "s1", nil)
+	if len(dr.columnNames) > 0 && !dr.matching.ExactNames {
+		dr.matchRenamed(entries)
+	}
+	for _, e := range entries {
+		dr.addToStats(e.key, e.ident.path, e.status, e.message)
+		dr.identities[e.key] = e.ident
+	}
 	dr.columnNames = append(dr.columnNames, column)
+	dr.errors = append(dr.errors, robot.Errors)
+	dr.tags = append(dr.tags, robot.TagStatistics())
 
 	// Add missing statuses for all rows.
 	for name, statuses := range dr.stats {
@@ -31,27 +59,22 @@ func (dr *DiffResults) AddParsedOutput(robot *Robot, column string) {
 	}
 }
 
-func (dr *DiffResults) addSuite(suite *Suite, parent string) {
-	longname := suite.Name
-	if parent != "" {
-		longname = parent + "." + suite.Name
-	}
+// pathSeparator joins the names of a path into a row key. Names may contain
+// dots, so the dotted long name cannot be used.
+const pathSeparator = "\x00"
 
-	dr.addToStats(longname, suite.Status.Status)
-
-	for i := range suite.Suites {
-		dr.addSuite(&suite.Suites[i], longname)
-	}
+// pathKey returns the row key of a suite or test path.
+func pathKey(path []string) string {
+	return strings.ToLower(strings.Join(path, pathSeparator))
+}
 
-	for _, test := range suite.Tests {
-		testLongname := longname + "." + test.Name
-		dr.addToStats(testLongname, test.Status.Status)
-	}
+// LongName joins a path into a Robot style dotted long name for display.
+func LongName(path []string) string {
+	return strings.Join(path, ".")
 }
 
-func (dr *DiffResults) addToStats(name, status string) {
-	normalizedName := strings.ToLower(name)
-	statuses, exists := dr.stats[normalizedName]
+func (dr *DiffResults) addToStats(key string, path []string, status, message string) {
+	statuses, exists := dr.stats[key]
 
 	if !exists {
 		statuses = make([]*ItemStatus, len(dr.columnNames), len(dr.columnNames)+4)
@@ -60,70 +83,160 @@ func (dr *DiffResults) addToStats(name, status string) {
 		}
 	}
 
-	var statusUpper, statusLower string
-	switch status {
-	case "PASS", "pass":
-		statusUpper, statusLower = "PASS", "pass"
-	case "FAIL", "fail":
-		statusUpper, statusLower = "FAIL", "fail"
-	default:
-		statusUpper = strings.ToUpper(status)
-		statusLower = strings.ToLower(status)
+	statusUpper := NormalizeStatus(status)
+	statusLower := strings.ReplaceAll(strings.ToLower(statusUpper), " ", "_")
+
+	statuses = append(statuses, &ItemStatus{Name: statusUpper, Status: statusLower, Message: strings.TrimSpace(message), LongName: LongName(path)})
+	dr.stats[key] = statuses
+}
+
+// ExecutionErrors returns the execution errors of column i.
+func (dr *DiffResults) ExecutionErrors(i int) []Message {
+	if i < 0 || i >= len(dr.errors) {
+		return nil
 	}
+	return dr.errors[i]
+}
 
-	statuses = append(statuses, &ItemStatus{Name: statusUpper, Status: statusLower})
-	dr.stats[normalizedName] = statuses
+// NewExecutionErrors returns the execution errors of column i that the
+// previous column does not have. The first column has no new errors.
+func (dr *DiffResults) NewExecutionErrors(i int) []Message {
+	if i <= 0 || i >= len(dr.errors) {
+		return nil
+	}
+	return NewExecutionErrors(dr.errors[i-1], dr.errors[i])
 }
 
-func (dr *DiffResults) Rows() []*RowStatus {
-	names := make([]string, 0, len(dr.stats))
-	for name := range dr.stats {
-		names = append(names, name)
-	}
-	sort.Strings(names)
-
-	hasChildren := make(map[string]bool)
-	for _, name := range names {
-		parts := strings.Split(name, ".")
-		for i := 1; i < len(parts); i++ {
-			parent := strings.Join(parts[:i], ".")
-			hasChildren[parent] = true
+// TagStatistics returns the per-tag statistics of column i.
+func (dr *DiffResults) TagStatistics(i int) []Stat {
+	if i < 0 || i >= len(dr.tags) {
+		return nil
+	}
+	return dr.tags[i]
+}
+
+// rowTree is a suite or test in the hierarchy of the rows. Nodes are matched
+// by lower-cased name; key is empty for suites that have no row of their own
+// (a renamed suite whose removed tests are still listed). A suite without
+// tests has no children but is not a test.
+type rowTree struct {
+	name     string
+	key      string
+	test     bool
+	children []*rowTree
+	byName   map[string]*rowTree
+}
+
+// tree arranges the rows by their paths. Children are in the order of their
+// row keys, which sort like the dotted long names.
+func (dr *DiffResults) tree() *rowTree {
+	type keyedIdentity struct {
+		key   string
+		ident rowIdentity
+	}
+	idents := make([]keyedIdentity, 0, len(dr.identities))
+	for key, ident := range dr.identities {
+		idents = append(idents, keyedIdentity{key, ident})
+	}
+	sort.Slice(idents, func(i, j int) bool { return idents[i].key < idents[j].key })
+
+	root := &rowTree{}
+	for _, ki := range idents {
+		node := root
+		for _, name := range ki.ident.path {
+			node = node.child(name)
 		}
+		node.key = ki.key
+		node.test = ki.ident.test
 	}
+	return root
+}
 
-	rows := make([]*RowStatus, 0, len(names))
-	for _, name := range names {
-		if !hasChildren[name] {
-			rows = append(rows, NewRowStatus(name, dr.stats[name]))
-			continue
+// child returns the child named name, adding it if needed. A new child takes
+// the spelling of name.
+func (t *rowTree) child(name string) *rowTree {
+	lower := strings.ToLower(name)
+	if c, ok := t.byName[lower]; ok {
+		return c
+	}
+	c := &rowTree{name: name}
+	if t.byName == nil {
+		t.byName = make(map[string]*rowTree)
+	}
+	t.byName[lower] = c
+	t.children = append(t.children, c)
+	return c
+}
+
+// hasTests reports whether any child is a test.
+func (t *rowTree) hasTests() bool {
+	for _, c := range t.children {
+		if c.test {
+			return true
 		}
+	}
+	return false
+}
 
-		hasLeafChildren := false
-		for _, otherName := range names {
-			if strings.HasPrefix(otherName, name+".") {
-				remainder := strings.TrimPrefix(otherName, name+".")
-				if !strings.Contains(remainder, ".") && !hasChildren[otherName] {
-					hasLeafChildren = true
-					break
-				}
+// Rows returns the rows of tests and of the suites that directly hold tests,
+// each suite before its children.
+func (dr *DiffResults) Rows() []*RowStatus {
+	rows := make([]*RowStatus, 0, len(dr.stats))
+	var walk func(node *rowTree)
+	walk = func(node *rowTree) {
+		for _, c := range node.children {
+			if c.key != "" && (c.test || c.hasTests()) {
+				rows = append(rows, dr.newRow(c.key))
 			}
-		}
-		if hasLeafChildren {
-			rows = append(rows, NewRowStatus(name, dr.stats[name]))
+			walk(c)
 		}
 	}
-
+	walk(dr.tree())
 	return rows
 }
 
+func (dr *DiffResults) newRow(key string) *RowStatus {
+	path := dr.identities[key].path
+	row := NewRowStatus(LongName(path), dr.stats[key])
+	row.Path = path
+	if rename, ok := dr.renames[key]; ok {
+		row.RenamedFrom = rename.from
+		row.MatchedBy = rename.by
+	}
+	return row
+}
+
+// NormalizeStatus returns the canonical upper-case spelling of a Robot status
+// ("PASS", "FAIL", "SKIP", "NOT RUN", ...).
+func NormalizeStatus(status string) string {
+	upper := strings.ToUpper(strings.TrimSpace(status))
+	switch upper {
+	case "NOT_RUN", "NOTRUN", "NOT RUN":
+		return "NOT RUN"
+	}
+	return upper
+}
+
 type ItemStatus struct {
-	Name   string
-	Status string
+	Name    string
+	Status  string
+	Message string
+	// LongName is the long name of the test in this column, which differs
+	// from the row's for renamed tests.
+	LongName string
 }
 
 type RowStatus struct {
+	// Name is the dotted long name and Path the suite and test names it is
+	// made of, as spelled in the last column that has the row.
 	Name     string
+	Path     []string
 	statuses []*ItemStatus
+	// RenamedFrom is the first name of a test matched across a rename and
+	// MatchedBy how it was matched (MatchBySource, ...); both are empty for
+	// other rows.
+	RenamedFrom string
+	MatchedBy   string
 }
 
 func NewRowStatus(name string, statuses []*ItemStatus) *RowStatus {
@@ -133,14 +246,18 @@ func NewRowStatus(name string, statuses []*ItemStatus) *RowStatus {
 func (rs *RowStatus) Status() string {
 	passed := false
 	failed := false
+	skipped := false
 	missing := false
 
 	for _, stat := range rs.statuses {
-		if stat.Name == "PASS" {
+		switch stat.Name {
+		case "PASS":
 			passed = true
-		} else if stat.Name == "FAIL" {
+		case "FAIL":
 			failed = true
-		} else if stat.Name == "N/A" {
+		case "SKIP", "NOT RUN":
+			skipped = true
+		case "N/A":
 			missing = true
 		}
 	}
@@ -148,28 +265,95 @@ func (rs *RowStatus) Status() string {
 	if passed && failed {
 		return "diff"
 	}
+	if skipped && (passed || failed) {
+		if from, to, ok := rs.lastTransition(); ok {
+			return statusKey(from) + "_to_" + statusKey(to)
+		}
+	}
 	if missing {
 		return "missing"
 	}
 	if passed {
 		return "all_passed"
 	}
+	if failed && rs.failedDifferently() {
+		return "failed_differently"
+	}
+	if failed {
+		return "all_failed"
+	}
+	if skipped {
+		return "all_skipped"
+	}
 	return "all_failed"
 }
 
+// lastTransition returns the most recent status change between two present
+// (non-missing) columns.
+func (rs *RowStatus) lastTransition() (from, to string, ok bool) {
+	prev := ""
+	for _, stat := range rs.statuses {
+		if stat.Name == "N/A" {
+			continue
+		}
+		if prev != "" && stat.Name != prev {
+			from, to, ok = prev, stat.Name, true
+		}
+		prev = stat.Name
+	}
+	return from, to, ok
+}
+
+func statusKey(status string) string {
+	return strings.ReplaceAll(strings.ToLower(status), " ", "_")
+}
+
+// failedDifferently reports whether the row failed in more than one column with
+// failure messages that differ after normalization.
+func (rs *RowStatus) failedDifferently() bool {
+	first := ""
+	seen := false
+	for _, stat := range rs.statuses {
+		if stat.Name != "FAIL" {
+			continue
+		}
+		msg := NormalizeFailureMessage(stat.Message)
+		if !seen {
+			first = msg
+			seen = true
+			continue
+		}
+		if msg != first {
+			return true
+		}
+	}
+	return false
+}
+
 func (rs *RowStatus) Explanation() string {
-	switch rs.Status() {
+	status := rs.Status()
+	switch status {
 	case "all_passed":
 		return "All passed"
 	case "all_failed":
 		return "All failed"
+	case "all_skipped":
+		return "All skipped"
 	case "missing":
 		return "Missing items"
 	case "diff":
 		return "Different statuses"
-	default:
-		return ""
+	case "failed_differently":
+		return "Failed with different messages"
+	}
+	if from, to, ok := strings.Cut(status, "_to_"); ok {
+		return statusLabel(from) + " → " + statusLabel(to)
 	}
+	return ""
+}
+
+func statusLabel(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "_", " "))
 }
 
 func (rs *RowStatus) Statuses() []*ItemStatus { return rs.statuses }
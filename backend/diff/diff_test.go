@@ -0,0 +1,171 @@
+package robodiff
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// syntheticRobot builds a result with the given number of tests in suite
+// files of 50 tests, grouped in directories of 20 files. Variants differ the
+// way consecutive runs do: a few tests fail, go missing or are renamed.
+func syntheticRobot(tests, variant int) *Robot {
+	const testsPerFile, filesPerDir = 50, 20
+	pass := Status{Status: "PASS"}
+	root := Suite{Name: "Tests", Source: "/src/tests", Status: pass}
+	for file := 0; file*testsPerFile < tests; file++ {
+		dir := file / filesPerDir
+		if dir == len(root.Suites) {
+			root.Suites = append(root.Suites, Suite{
+				Name:   fmt.Sprintf("Area %d", dir),
+				Source: fmt.Sprintf("/src/tests/area_%d", dir),
+				Status: pass,
+			})
+		}
+		suite := Suite{
+			Name:   fmt.Sprintf("Feature %d.%d", dir, file),
+			Source: fmt.Sprintf("/src/tests/area_%d/feature_%d.robot", dir, file),
+			Status: pass,
+		}
+		for i := file * testsPerFile; i < (file+1)*testsPerFile && i < tests; i++ {
+			if (i*7+variant)%101 == 0 {
+				continue
+			}
+			name := fmt.Sprintf("Test %d", i)
+			if variant > 0 && i%503 == variant {
+				name += " Renamed"
+			}
+			status := pass
+			if (i+variant)%97 == 0 {
+				status = Status{Status: "FAIL", Message: fmt.Sprintf("Expected %d but got %d", i, variant)}
+			}
+			suite.Tests = append(suite.Tests, Test{Name: name, Status: status})
+		}
+		parent := &root.Suites[dir]
+		parent.Suites = append(parent.Suites, suite)
+	}
+	return &Robot{Suite: root}
+}
+
+// TestSyntheticRobotRows checks the rows of two synthetic runs, so the
+// benchmarks below measure a diff that is also correct. With 200 tests the
+// runs have one directory of four suite files. The first run lacks tests 0
+// and 101 and fails 97 and 194; the second lacks 72 and 173, fails 96 and 193
+// and renames test 1.
+func TestSyntheticRobotRows(t *testing.T) {
+	results := NewDiffResults()
+	results.AddParsedOutput(syntheticRobot(200, 0), "run 1")
+	results.AddParsedOutput(syntheticRobot(200, 1), "run 2")
+	rows := results.Rows()
+
+	if want := 4 + 200; len(rows) != want {
+		t.Fatalf("got %d rows, want %d", len(rows), want)
+	}
+	var suites []string
+	var suite []string
+	changed := make(map[string]string)
+	for _, row := range rows {
+		switch len(row.Path) {
+		case 3:
+			suite = row.Path
+			suites = append(suites, row.Name)
+		case 4:
+			if suite == nil || !reflect.DeepEqual(row.Path[:3], suite) {
+				t.Errorf("test %s listed after suite %v", row.Name, suite)
+			}
+		default:
+			t.Errorf("row %s has path %v", row.Name, row.Path)
+			continue
+		}
+		if status := row.Status(); status != "all_passed" {
+			changed[row.Name] = status
+		}
+		if row.RenamedFrom != "" {
+			changed[row.Name] += " from " + row.RenamedFrom
+		}
+	}
+	wantSuites := []string{
+		"Tests.Area 0.Feature 0.0",
+		"Tests.Area 0.Feature 0.1",
+		"Tests.Area 0.Feature 0.2",
+		"Tests.Area 0.Feature 0.3",
+	}
+	if !reflect.DeepEqual(suites, wantSuites) {
+		t.Errorf("suite rows %v, want %v", suites, wantSuites)
+	}
+	wantChanged := map[string]string{
+		"Tests.Area 0.Feature 0.0.Test 0":         "missing",
+		"Tests.Area 0.Feature 0.0.Test 1 Renamed": " from Tests.Area 0.Feature 0.0.Test 1",
+		"Tests.Area 0.Feature 0.1.Test 72":        "missing",
+		"Tests.Area 0.Feature 0.1.Test 96":        "diff",
+		"Tests.Area 0.Feature 0.1.Test 97":        "diff",
+		"Tests.Area 0.Feature 0.2.Test 101":       "missing",
+		"Tests.Area 0.Feature 0.3.Test 173":       "missing",
+		"Tests.Area 0.Feature 0.3.Test 193":       "diff",
+		"Tests.Area 0.Feature 0.3.Test 194":       "diff",
+	}
+	if !reflect.DeepEqual(changed, wantChanged) {
+		t.Errorf("changed rows:\n got %v\nwant %v", changed, wantChanged)
+	}
+
+	// Test 0 takes the id Test 1 had in the first run; it is still a new
+	// test and the renamed one keeps its results.
+	byName := make(map[string]*RowStatus, len(rows))
+	for _, row := range rows {
+		byName[row.Name] = row
+	}
+	renamed := byName["Tests.Area 0.Feature 0.0.Test 1 Renamed"]
+	if renamed == nil || renamed.MatchedBy != MatchBySimilarity {
+		t.Fatalf("Test 1 Renamed not matched to Test 1 by similarity: %+v", renamed)
+	}
+	if got := []string{renamed.Statuses()[0].Name, renamed.Statuses()[1].Name}; !reflect.DeepEqual(got, []string{"PASS", "PASS"}) {
+		t.Errorf("Test 1 Renamed statuses %v, want PASS in both runs", got)
+	}
+	added := byName["Tests.Area 0.Feature 0.0.Test 0"]
+	if added == nil || added.RenamedFrom != "" {
+		t.Fatalf("Test 0 is not a new row: %+v", added)
+	}
+	if got := []string{added.Statuses()[0].Name, added.Statuses()[1].Name}; !reflect.DeepEqual(got, []string{"N/A", "PASS"}) {
+		t.Errorf("Test 0 statuses %v, want N/A then PASS", got)
+	}
+	if _, ok := byName["Tests.Area 0.Feature 0.0.Test 1"]; ok {
+		t.Errorf("old name of Test 1 Renamed still listed")
+	}
+}
+
+func BenchmarkDiff(b *testing.B) {
+	const runs = 5
+	for _, tests := range []int{1000, 10000, 60000} {
+		robots := make([]*Robot, runs)
+		columns := make([]string, runs)
+		for i := range robots {
+			robots[i] = syntheticRobot(tests, i)
+			columns[i] = fmt.Sprintf("run %d", i+1)
+		}
+		b.Run(fmt.Sprintf("tests=%d", tests), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				results := NewDiffResults()
+				for i, robot := range robots {
+					results.AddParsedOutput(robot, columns[i])
+				}
+				NewDiffReporter("Robodiff", columns, nil).BuildJSONData(results)
+			}
+		})
+	}
+}
+
+func BenchmarkRows(b *testing.B) {
+	for _, tests := range []int{1000, 10000, 60000} {
+		results := NewDiffResults()
+		for i := 0; i < 5; i++ {
+			results.AddParsedOutput(syntheticRobot(tests, i), fmt.Sprintf("run %d", i+1))
+		}
+		b.Run(fmt.Sprintf("tests=%d", tests), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				results.Rows()
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package robodiff
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+)
+
+// Execution errors: the messages Robot writes to the top-level <errors>
+// element (the "Test Execution Errors" table of log.html).
+
+// decodeErrors reads the <msg> children of an <errors> element.
+func decodeErrors(d *xml.Decoder, start xml.StartElement) ([]Message, error) {
+	var wrapped struct {
+		Messages []Message `xml:"msg"`
+	}
+	if err := d.DecodeElement(&wrapped, &start); err != nil {
+		return nil, err
+	}
+	return wrapped.Messages, nil
+}
+
+// decodeJSONErrors reads the "errors" array of a JSON result.
+func decodeJSONErrors(dec *json.Decoder) ([]Message, error) {
+	var items []jsonItem
+	if err := dec.Decode(&items); err != nil {
+		return nil, err
+	}
+	msgs := make([]Message, len(items))
+	for i, it := range items {
+		msgs[i] = Message{Level: it.Level, Timestamp: it.Timestamp, HTML: it.HTML, Text: it.Message}
+	}
+	return msgs, nil
+}
+
+// CountExecutionErrors counts ERROR (and FAIL) and WARN level messages.
+func CountExecutionErrors(msgs []Message) (errors, warnings int) {
+	for _, msg := range msgs {
+		switch strings.ToUpper(strings.TrimSpace(msg.Level)) {
+		case "ERROR", "FAIL":
+			errors++
+		case "WARN":
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// NewExecutionErrors returns the messages of after that do not occur in
+// before. Messages are compared by level and by their text with run-specific
+// noise removed (see NormalizeFailureMessage).
+func NewExecutionErrors(before, after []Message) []Message {
+	seen := make(map[string]int, len(before))
+	for _, msg := range before {
+		seen[executionErrorKey(msg)]++
+	}
+	var out []Message
+	for _, msg := range after {
+		key := executionErrorKey(msg)
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+func executionErrorKey(msg Message) string {
+	return strings.ToUpper(strings.TrimSpace(msg.Level)) + "\x00" + NormalizeFailureMessage(msg.Text)
+}
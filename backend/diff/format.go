@@ -0,0 +1,284 @@
+package robodiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Regression is a test that passed in one column and failed in the next one.
+type Regression struct {
+	Suite string
+	Test  string
+	From  string
+	To    string
+}
+
+// Regressions lists PASS->FAIL transitions between adjacent columns.
+func (r *JSONReport) Regressions() []Regression {
+	var out []Regression
+	for _, suite := range r.AllSuites() {
+		for _, test := range suite.Tests {
+			for i := 1; i < len(test.Results); i++ {
+				if test.Results[i-1] == "PASS" && test.Results[i] == "FAIL" {
+					out = append(out, Regression{
+						Suite: suite.LongName(),
+						Test:  test.Name,
+						From:  columnName(r.Columns, i-1),
+						To:    columnName(r.Columns, i),
+					})
+				}
+			}
+		}
+	}
+	return out
+}
+
+func columnName(columns []string, i int) string {
+	if i < len(columns) {
+		return columns[i]
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+func testChanged(test JSONTest) bool {
+	if test.Status == "failed_differently" || test.RenamedFrom != "" {
+		return true
+	}
+	for i := 1; i < len(test.Results); i++ {
+		if test.Results[i] != test.Results[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSON writes the report as indented JSON (same shape as /api/diff).
+func WriteJSON(w io.Writer, report *JSONReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WriteText writes the report as an aligned plain-text table. When onlyChanged
+// is set, tests with the same result (and failure cause) in every column are
+// left out.
+func WriteText(w io.Writer, report *JSONReport, onlyChanged bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if report.Title != "" {
+		fmt.Fprintf(tw, "%s\n\n", report.Title)
+	}
+	fmt.Fprintf(tw, "TEST\t%s\tSTATUS\n", strings.Join(report.Columns, "\t"))
+	for _, suite := range report.AllSuites() {
+		for _, test := range suite.Tests {
+			if onlyChanged && !testChanged(test) {
+				continue
+			}
+			fmt.Fprintf(tw, "%s.%s\t%s\t%s\n", suite.LongName(), test.Name, strings.Join(test.Results, "\t"), test.Explanation)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if err := writeTextTagSummary(w, report, onlyChanged); err != nil {
+		return err
+	}
+	if err := writeRegressionSummary(w, report, ""); err != nil {
+		return err
+	}
+	return writeNewErrors(w, report)
+}
+
+// WriteMarkdown writes the report as a GitHub-flavored Markdown document with
+// one table per suite.
+func WriteMarkdown(w io.Writer, report *JSONReport, onlyChanged bool) error {
+	title := report.Title
+	if title == "" {
+		title = "Robodiff"
+	}
+	if _, err := fmt.Fprintf(w, "# %s\n", markdownEscape(title)); err != nil {
+		return err
+	}
+
+	header := "| Test | " + strings.Join(escapeAll(report.Columns), " | ") + " | Status |\n"
+	sep := "|---" + strings.Repeat("|---", len(report.Columns)+1) + "|\n"
+	for _, suite := range report.AllSuites() {
+		rows := make([]string, 0, len(suite.Tests))
+		for _, test := range suite.Tests {
+			if onlyChanged && !testChanged(test) {
+				continue
+			}
+			rows = append(rows, "| "+markdownEscape(test.Name)+" | "+strings.Join(markdownResults(test.Results), " | ")+" | "+markdownEscape(test.Explanation)+" |\n")
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n## %s\n\n%s%s%s", markdownEscape(suite.LongName()), header, sep, strings.Join(rows, "")); err != nil {
+			return err
+		}
+	}
+	if err := writeMarkdownTagSummary(w, report, onlyChanged); err != nil {
+		return err
+	}
+	if err := writeRegressionSummary(w, report, "\n"); err != nil {
+		return err
+	}
+	return writeNewErrors(w, report)
+}
+
+// tagSummaryRows returns the tag summary rows to print.
+func tagSummaryRows(report *JSONReport, onlyChanged bool) []JSONTagStat {
+	var rows []JSONTagStat
+	for _, tag := range report.Tags {
+		if onlyChanged && !tag.Changed() {
+			continue
+		}
+		rows = append(rows, tag)
+	}
+	return rows
+}
+
+// writeTextTagSummary writes pass/fail/skip per tag and column. Nothing is
+// written when no test has tags.
+func writeTextTagSummary(w io.Writer, report *JSONReport, onlyChanged bool) error {
+	rows := tagSummaryRows(report, onlyChanged)
+	if len(rows) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprint(w, "\nTag summary (pass/fail/skip):\n"); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TAG\t%s\tCHANGE\n", strings.Join(report.Columns, "\t"))
+	for _, tag := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", tag.Tag, strings.Join(tagCounts(tag, "/"), "\t"), tagChange(tag))
+	}
+	return tw.Flush()
+}
+
+// writeMarkdownTagSummary is writeTextTagSummary as a Markdown table.
+func writeMarkdownTagSummary(w io.Writer, report *JSONReport, onlyChanged bool) error {
+	rows := tagSummaryRows(report, onlyChanged)
+	if len(rows) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("\n## Tag summary\n\nPass / fail / skip per tag.\n\n")
+	b.WriteString("| Tag | " + strings.Join(escapeAll(report.Columns), " | ") + " | Change |\n")
+	b.WriteString("|---" + strings.Repeat("|---", len(report.Columns)+1) + "|\n")
+	for _, tag := range rows {
+		b.WriteString("| " + markdownEscape(tag.Tag) + " | " + strings.Join(tagCounts(tag, " / "), " | ") + " | " + markdownEscape(tagChange(tag)) + " |\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tagCounts formats the counts of each column as pass/fail/skip, "-" where
+// the tag is missing.
+func tagCounts(tag JSONTagStat, sep string) []string {
+	out := make([]string, len(tag.Counts))
+	for i, c := range tag.Counts {
+		if c == nil {
+			out[i] = "-"
+			continue
+		}
+		out[i] = fmt.Sprintf("%d%s%d%s%d", c.Pass, sep, c.Fail, sep, c.Skip)
+	}
+	return out
+}
+
+// tagChange describes how the counts of a tag move across the columns, e.g.
+// "40->37 passing, 0->3 failing".
+func tagChange(tag JSONTagStat) string {
+	if !tag.Changed() {
+		return ""
+	}
+	var parts []string
+	for _, field := range []struct {
+		label string
+		value func(*JSONStatCount) int
+	}{
+		{"passing", func(c *JSONStatCount) int { return c.Pass }},
+		{"failing", func(c *JSONStatCount) int { return c.Fail }},
+		{"skipped", func(c *JSONStatCount) int { return c.Skip }},
+	} {
+		values := make([]string, len(tag.Counts))
+		changed := false
+		for i, c := range tag.Counts {
+			values[i] = "-"
+			if c != nil {
+				values[i] = fmt.Sprint(field.value(c))
+			}
+			if values[i] != values[0] {
+				changed = true
+			}
+		}
+		if changed {
+			parts = append(parts, strings.Join(values, "->")+" "+field.label)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func writeRegressionSummary(w io.Writer, report *JSONReport, prefix string) error {
+	regressions := report.Regressions()
+	if len(regressions) == 0 {
+		_, err := fmt.Fprintf(w, "%s\nNo regressions.\n", prefix)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n%d regression(s):\n", prefix, len(regressions)); err != nil {
+		return err
+	}
+	for _, reg := range regressions {
+		if _, err := fmt.Fprintf(w, "- %s.%s (%s -> %s)\n", reg.Suite, reg.Test, reg.From, reg.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNewErrors lists execution errors that are new in a column compared to
+// the previous one. Nothing is written when there are none.
+func writeNewErrors(w io.Writer, report *JSONReport) error {
+	for i, col := range report.Errors {
+		if len(col.New) == 0 {
+			continue
+		}
+		from := columnName(report.Columns, i-1)
+		if _, err := fmt.Fprintf(w, "\n%d new execution error(s) in %s (vs %s):\n", len(col.New), col.Column, from); err != nil {
+			return err
+		}
+		for _, e := range col.New {
+			if _, err := fmt.Fprintf(w, "- [%s] %s\n", e.Level, strings.ReplaceAll(e.Message, "\n", " ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func markdownResults(results []string) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		if r == "FAIL" || r == "MISSING" {
+			out[i] = "**" + r + "**"
+		} else {
+			out[i] = r
+		}
+	}
+	return out
+}
+
+func escapeAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = markdownEscape(v)
+	}
+	return out
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
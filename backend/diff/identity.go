@@ -0,0 +1,444 @@
+package robodiff
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rows are keyed by the lower-cased path of suite and test names (pathKey),
+// so a test that was renamed or moved to another suite would show up as a
+// missing row plus a new one. When a column is added, the tests whose name is
+// new are matched against the rows the column lacks, in this order:
+//
+//   - the same test name in a suite with the same source file (the suite was
+//     renamed or moved);
+//   - the same Robot id (s1-s2-t3) in a suite with the same source file (the
+//     test was renamed in place), when the suite's other tests keep their
+//     ids;
+//   - the same test name anywhere, when only one test on each side has it
+//     (the test moved to another file);
+//   - a similar name in a suite with the same source file;
+//   - the same Robot id in a suite whose tests were added, removed or
+//     reordered. Ids are positional, so there they only pair what the
+//     names could not.
+//
+// Suites without a source (JUnit results) use their path instead. Each
+// row is matched at most once; a matched row takes the new name and remembers
+// the one it had first.
+
+// Values of RowStatus.MatchedBy.
+const (
+	MatchBySource     = "source"
+	MatchByID         = "id"
+	MatchByName       = "name"
+	MatchBySimilarity = "similarity"
+)
+
+// DefaultRenameSimilarity is the name similarity (see nameSimilarity) above
+// which two tests of the same suite are taken to be one renamed test.
+const DefaultRenameSimilarity = 0.7
+
+// MatchOptions tunes how NewDiffResultsWithOptions matches tests whose long
+// name changed between columns.
+type MatchOptions struct {
+	// ExactNames matches rows by long name only.
+	ExactNames bool
+	// Similarity is the minimum name similarity, between 0 and 1, for a
+	// rename within a suite. Zero means DefaultRenameSimilarity; above 1
+	// turns similarity matching off.
+	Similarity float64
+}
+
+// rowIdentity describes the suite or test behind a row as last seen.
+type rowIdentity struct {
+	test   bool
+	id     string
+	path   []string
+	name   string // lower-cased test or suite name
+	suite  string // row key of the parent suite
+	source string // source file of the suite (the parent suite for tests)
+}
+
+// scope groups tests of the same suite file.
+func (ri rowIdentity) scope() string {
+	if ri.source != "" {
+		return "source:" + ri.source
+	}
+	return "suite:" + ri.suite
+}
+
+// rowRename records the first long name of a row matched to a renamed test.
+type rowRename struct {
+	from string
+	by   string
+}
+
+// columnEntry is one suite or test of a column being added.
+type columnEntry struct {
+	key     string
+	status  string
+	message string
+	ident   rowIdentity
+}
+
+// collectEntries lists the suites and tests of suite, each suite before its
+// children, numbering them the way Robot assigns ids.
+func collectEntries(suite *Suite, parent []string, id string, out []columnEntry) []columnEntry {
+	path := append(parent[:len(parent):len(parent)], suite.Name)
+	key := pathKey(path)
+	out = append(out, columnEntry{
+		key:     key,
+		status:  suite.Status.Status,
+		message: suite.Status.Message,
+		ident: rowIdentity{
+			id:     id,
+			path:   path,
+			name:   strings.ToLower(suite.Name),
+			suite:  pathKey(parent),
+			source: suite.Source,
+		},
+	})
+	for i := range suite.Suites {
+		out = collectEntries(&suite.Suites[i], path, id+"-s"+strconv.Itoa(i+1), out)
+	}
+	for i, test := range suite.Tests {
+		testPath := append(path[:len(path):len(path)], test.Name)
+		out = append(out, columnEntry{
+			key:     pathKey(testPath),
+			status:  test.Status.Status,
+			message: test.Status.Message,
+			ident: rowIdentity{
+				test:   true,
+				id:     id + "-t" + strconv.Itoa(i+1),
+				path:   testPath,
+				name:   strings.ToLower(test.Name),
+				suite:  key,
+				source: suite.Source,
+			},
+		})
+	}
+	return out
+}
+
+// matchRenamed moves the rows of renamed suites and tests to the key they
+// have in entries, so the column's results are added to the existing rows.
+func (dr *DiffResults) matchRenamed(entries []columnEntry) {
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		present[e.key] = true
+	}
+	var goneSuites, goneTests []string
+	for key := range dr.stats {
+		ident, ok := dr.identities[key]
+		if present[key] || !ok {
+			continue
+		}
+		if ident.test {
+			goneTests = append(goneTests, key)
+		} else {
+			goneSuites = append(goneSuites, key)
+		}
+	}
+	if len(goneSuites) == 0 && len(goneTests) == 0 {
+		return
+	}
+	sort.Strings(goneSuites)
+	sort.Strings(goneTests)
+
+	var newSuites, newTests []*columnEntry
+	for i := range entries {
+		e := &entries[i]
+		if _, ok := dr.stats[e.key]; ok {
+			continue
+		}
+		if e.ident.test {
+			newTests = append(newTests, e)
+		} else {
+			newSuites = append(newSuites, e)
+		}
+	}
+
+	// Whether ids can pair tests is decided on the rows as they are before
+	// any of them is matched.
+	stable := dr.idStableScopes(entries, goneTests)
+
+	// A suite keeps its row when its file is the only one with that source
+	// on both sides; its tests are matched below.
+	dr.pairUnique(goneSuites, newSuites, "", func(ri rowIdentity) string { return ri.source })
+
+	goneTests, newTests = dr.pairUnique(goneTests, newTests, MatchBySource, func(ri rowIdentity) string {
+		return ri.scope() + "\x00" + ri.name
+	})
+	byID := func(ri rowIdentity) string {
+		return ri.scope() + "\x00" + ri.id
+	}
+	goneTests, newTests = dr.pairUnique(goneTests, newTests, MatchByID, func(ri rowIdentity) string {
+		if !stable[ri.scope()] {
+			return ""
+		}
+		return byID(ri)
+	})
+	goneTests, newTests = dr.pairUnique(goneTests, newTests, MatchByName, func(ri rowIdentity) string {
+		return ri.name
+	})
+	goneTests, newTests = dr.pairSimilar(goneTests, newTests)
+	dr.pairUnique(goneTests, newTests, MatchByID, byID)
+}
+
+// idStableScopes returns the suite files whose tests keep their ids from the
+// last column to entries: the file has as many tests as before and each id
+// either keeps its test name or goes from a gone test to a new one. Elsewhere
+// a test added or removed before others shifts their ids.
+func (dr *DiffResults) idStableScopes(entries []columnEntry, gone []string) map[string]bool {
+	// Files are told apart by the parts of scope(), which saves building
+	// its string for every test; only files a gone test was in are checked.
+	type file struct{ source, suite string }
+	fileOf := func(ri rowIdentity) file {
+		if ri.source != "" {
+			return file{source: ri.source}
+		}
+		return file{suite: ri.suite}
+	}
+	isGone := make(map[string]bool, len(gone))
+	before := make(map[file]map[string]string)
+	for _, key := range gone {
+		isGone[key] = true
+		before[fileOf(dr.identities[key])] = make(map[string]string)
+	}
+	last := len(dr.columnNames) - 1
+	for key, ident := range dr.identities {
+		if !ident.test {
+			continue
+		}
+		if ids, ok := before[fileOf(ident)]; ok && dr.stats[key][last].Name != "N/A" {
+			ids[ident.id] = key
+		}
+	}
+	after := make(map[file]map[string]*columnEntry, len(before))
+	for i := range entries {
+		e := &entries[i]
+		if !e.ident.test {
+			continue
+		}
+		f := fileOf(e.ident)
+		if _, ok := before[f]; !ok {
+			continue
+		}
+		if after[f] == nil {
+			after[f] = make(map[string]*columnEntry)
+		}
+		after[f][e.ident.id] = e
+	}
+
+	stable := make(map[string]bool, len(after))
+	for f, tests := range after {
+		if !dr.keepsIDs(before[f], tests, isGone) {
+			continue
+		}
+		if f.source != "" {
+			stable[rowIdentity{source: f.source}.scope()] = true
+		} else {
+			stable[rowIdentity{suite: f.suite}.scope()] = true
+		}
+	}
+	return stable
+}
+
+// keepsIDs reports whether the tests of a file, by id, are the same before
+// and after except for gone tests replaced by new ones.
+func (dr *DiffResults) keepsIDs(before map[string]string, after map[string]*columnEntry, isGone map[string]bool) bool {
+	if len(before) != len(after) {
+		return false
+	}
+	for id, key := range before {
+		e := after[id]
+		if e == nil {
+			return false
+		}
+		if dr.identities[key].name == e.ident.name {
+			continue
+		}
+		if _, known := dr.stats[e.key]; known || !isGone[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// pairUnique matches gone rows and new entries that have the same non-empty
+// key and are the only ones with it on each side. It returns what is left.
+func (dr *DiffResults) pairUnique(gone []string, added []*columnEntry, by string, key func(rowIdentity) string) ([]string, []*columnEntry) {
+	if len(gone) == 0 || len(added) == 0 {
+		return gone, added
+	}
+	goneByKey := make(map[string][]string, len(gone))
+	for _, k := range gone {
+		if id := key(dr.identities[k]); id != "" {
+			goneByKey[id] = append(goneByKey[id], k)
+		}
+	}
+	addedByKey := make(map[string][]*columnEntry, len(added))
+	for _, e := range added {
+		if id := key(e.ident); id != "" {
+			addedByKey[id] = append(addedByKey[id], e)
+		}
+	}
+	matched := make(map[string]bool)
+	var restAdded []*columnEntry
+	for _, e := range added {
+		id := key(e.ident)
+		if olds := goneByKey[id]; id != "" && len(olds) == 1 && len(addedByKey[id]) == 1 {
+			dr.rekey(olds[0], e, by)
+			matched[olds[0]] = true
+			continue
+		}
+		restAdded = append(restAdded, e)
+	}
+	restGone := gone[:0:0]
+	for _, k := range gone {
+		if !matched[k] {
+			restGone = append(restGone, k)
+		}
+	}
+	return restGone, restAdded
+}
+
+// pairSimilar matches the remaining tests of the same suite file by name
+// similarity, best pairs first. It returns what is left.
+func (dr *DiffResults) pairSimilar(gone []string, added []*columnEntry) ([]string, []*columnEntry) {
+	threshold := dr.matching.Similarity
+	if threshold == 0 {
+		threshold = DefaultRenameSimilarity
+	}
+	if threshold > 1 || len(gone) == 0 || len(added) == 0 {
+		return gone, added
+	}
+	goneByScope := make(map[string][]string)
+	for _, k := range gone {
+		scope := dr.identities[k].scope()
+		goneByScope[scope] = append(goneByScope[scope], k)
+	}
+	type candidate struct {
+		old   string
+		entry *columnEntry
+		score float64
+	}
+	var candidates []candidate
+	for _, e := range added {
+		for _, old := range goneByScope[e.ident.scope()] {
+			if score := nameSimilarity(dr.identities[old].name, e.ident.name); score >= threshold {
+				candidates = append(candidates, candidate{old, e, score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	usedOld := make(map[string]bool)
+	usedNew := make(map[*columnEntry]bool)
+	for _, c := range candidates {
+		if usedOld[c.old] || usedNew[c.entry] {
+			continue
+		}
+		dr.rekey(c.old, c.entry, MatchBySimilarity)
+		usedOld[c.old] = true
+		usedNew[c.entry] = true
+	}
+
+	restGone := gone[:0:0]
+	for _, k := range gone {
+		if !usedOld[k] {
+			restGone = append(restGone, k)
+		}
+	}
+	var restAdded []*columnEntry
+	for _, e := range added {
+		if !usedNew[e] {
+			restAdded = append(restAdded, e)
+		}
+	}
+	return restGone, restAdded
+}
+
+// rekey moves the row old to the key of e. Tests remember their first name;
+// by is empty for suites.
+func (dr *DiffResults) rekey(old string, e *columnEntry, by string) {
+	if _, exists := dr.stats[e.key]; exists {
+		return
+	}
+	from := LongName(dr.identities[old].path)
+	dr.stats[e.key] = dr.stats[old]
+	delete(dr.stats, old)
+	dr.identities[e.key] = e.ident
+	delete(dr.identities, old)
+	if by == "" {
+		return
+	}
+	if prev, ok := dr.renames[old]; ok {
+		from = prev.from
+		delete(dr.renames, old)
+	}
+	if !strings.EqualFold(from, LongName(e.ident.path)) {
+		dr.renames[e.key] = rowRename{from: from, by: by}
+	}
+}
+
+// nameSimilarity compares two names by the character pairs they share (the
+// Sørensen–Dice coefficient), ignoring case, spaces and underscores like
+// Robot does. It returns 1 for equal names and 0 for unrelated ones. Adding
+// words before or after a name is a common rename, so a name whose words run
+// on in the other scores at least halfway between the share of words kept
+// and 1: "Test 1" is closer to "Test 1 Renamed" than to "Test 0".
+func nameSimilarity(a, b string) float64 {
+	words := wordsContained(a, b)
+	a, b = normalizeTag(a), normalizeTag(b)
+	if a == b {
+		return 1
+	}
+	return max(words, diceCoefficient(a, b))
+}
+
+// wordsContained returns (1 + kept/all)/2 when the words of the shorter name
+// appear in order and next to each other in the longer one, and 0 otherwise.
+func wordsContained(a, b string) float64 {
+	split := func(name string) []string {
+		return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+			return r == ' ' || r == '_' || r == '\t'
+		})
+	}
+	short, long := split(a), split(b)
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) == 0 || len(short) == len(long) {
+		return 0
+	}
+	for i := 0; i+len(short) <= len(long); i++ {
+		if slices.Equal(long[i:i+len(short)], short) {
+			return (1 + float64(len(short))/float64(len(long))) / 2
+		}
+	}
+	return 0
+}
+
+func diceCoefficient(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 2 || len(rb) < 2 {
+		return 0
+	}
+	pairs := make(map[string]int, len(ra))
+	for i := 0; i+1 < len(ra); i++ {
+		pairs[string(ra[i:i+2])]++
+	}
+	shared := 0
+	for i := 0; i+1 < len(rb); i++ {
+		pair := string(rb[i : i+2])
+		if pairs[pair] > 0 {
+			pairs[pair]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ra)+len(rb)-2)
+}
@@ -0,0 +1,221 @@
+package robodiff
+
+import (
+	"math"
+	"testing"
+)
+
+// identitySuite is a suite file whose tests all pass.
+func identitySuite(name, source string, tests ...string) Suite {
+	suite := Suite{Name: name, Source: source, Status: Status{Status: "PASS"}}
+	for _, test := range tests {
+		suite.Tests = append(suite.Tests, Test{Name: test, Status: Status{Status: "PASS"}})
+	}
+	return suite
+}
+
+func identityRobot(suites ...Suite) *Robot {
+	return &Robot{Suite: Suite{Name: "Tests", Source: "/t", Suites: suites, Status: Status{Status: "PASS"}}}
+}
+
+func TestMatchRenamed(t *testing.T) {
+	similarity := nameSimilarity("Login works", "Login works fine")
+
+	// Before and after for the similarity cases: the renamed test moves to
+	// the first position so the id does not match.
+	similarBefore := identityRobot(identitySuite("Login", "/t/login.robot", "Keep", "Login works"))
+	similarAfter := identityRobot(identitySuite("Login", "/t/login.robot", "Login works fine", "Keep"))
+	suiteBefore := identityRobot(identitySuite("Login", "/t/login.robot", "Valid Login"))
+	suiteAfter := identityRobot(identitySuite("Auth", "/t/login.robot", "Valid Login"))
+
+	type rename struct{ from, by string }
+	tests := []struct {
+		name          string
+		opts          MatchOptions
+		before, after *Robot
+		// renamed maps the tests expected under their new name to how they
+		// were matched; missing lists rows expected to lack a column.
+		renamed map[string]rename
+		missing []string
+	}{
+		{
+			name:    "renamed suite with the same source",
+			before:  suiteBefore,
+			after:   suiteAfter,
+			renamed: map[string]rename{"Tests.Auth.Valid Login": {"Tests.Login.Valid Login", MatchBySource}},
+		},
+		{
+			name:    "in-place rename matched by id",
+			before:  identityRobot(identitySuite("Login", "/t/login.robot", "Valid Login", "Logout")),
+			after:   identityRobot(identitySuite("Login", "/t/login.robot", "Sign In With Password", "Logout")),
+			renamed: map[string]rename{"Tests.Login.Sign In With Password": {"Tests.Login.Valid Login", MatchByID}},
+		},
+		{
+			name:    "id of a test shifted by an added test is not a rename",
+			before:  identityRobot(identitySuite("Login", "/t/login.robot", "Valid Login", "Logout")),
+			after:   identityRobot(identitySuite("Login", "/t/login.robot", "Sign Up", "Valid Login Twice", "Logout")),
+			renamed: map[string]rename{"Tests.Login.Valid Login Twice": {"Tests.Login.Valid Login", MatchBySimilarity}},
+			missing: []string{"Tests.Login.Sign Up"},
+		},
+		{
+			name:    "id still pairs what names cannot in a changed suite",
+			before:  identityRobot(identitySuite("Login", "/t/login.robot", "Valid Login", "Logout")),
+			after:   identityRobot(identitySuite("Login", "/t/login.robot", "Sign In With Password", "Logout", "Session Expires")),
+			renamed: map[string]rename{"Tests.Login.Sign In With Password": {"Tests.Login.Valid Login", MatchByID}},
+			missing: []string{"Tests.Login.Session Expires"},
+		},
+		{
+			name:   "moved test unique by name",
+			before: identityRobot(identitySuite("Login", "/t/login.robot", "Valid Login", "Logout")),
+			after: identityRobot(
+				identitySuite("Login", "/t/login.robot", "Valid Login"),
+				identitySuite("Session", "/t/session.robot", "Logout"),
+			),
+			renamed: map[string]rename{"Tests.Session.Logout": {"Tests.Login.Logout", MatchByName}},
+		},
+		{
+			name: "two same-named candidates do not match",
+			before: identityRobot(
+				identitySuite("Login", "/t/login.robot", "Keep", "Logout"),
+				identitySuite("Admin", "/t/admin.robot", "Keep Admin", "Logout"),
+			),
+			after: identityRobot(
+				identitySuite("Login", "/t/login.robot", "Keep"),
+				identitySuite("Admin", "/t/admin.robot", "Keep Admin"),
+				identitySuite("Session", "/t/session.robot", "Logout"),
+			),
+			missing: []string{"Tests.Login.Logout", "Tests.Admin.Logout", "Tests.Session.Logout"},
+		},
+		{
+			name:    "similar name with the default threshold",
+			before:  similarBefore,
+			after:   similarAfter,
+			renamed: map[string]rename{"Tests.Login.Login works fine": {"Tests.Login.Login works", MatchBySimilarity}},
+		},
+		{
+			name:    "similarity equal to the threshold",
+			opts:    MatchOptions{Similarity: similarity},
+			before:  similarBefore,
+			after:   similarAfter,
+			renamed: map[string]rename{"Tests.Login.Login works fine": {"Tests.Login.Login works", MatchBySimilarity}},
+		},
+		{
+			name:    "similarity just below the threshold",
+			opts:    MatchOptions{Similarity: math.Nextafter(similarity, 1)},
+			before:  similarBefore,
+			after:   similarAfter,
+			missing: []string{"Tests.Login.Login works", "Tests.Login.Login works fine"},
+		},
+		{
+			name:    "similarity above 1 turns similarity matching off",
+			opts:    MatchOptions{Similarity: 1.5},
+			before:  similarBefore,
+			after:   similarAfter,
+			missing: []string{"Tests.Login.Login works", "Tests.Login.Login works fine"},
+		},
+		{
+			name:    "exact names",
+			opts:    MatchOptions{ExactNames: true},
+			before:  suiteBefore,
+			after:   suiteAfter,
+			missing: []string{"Tests.Login.Valid Login", "Tests.Auth.Valid Login"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := NewDiffResultsWithOptions(tt.opts)
+			results.AddParsedOutput(tt.before, "before")
+			results.AddParsedOutput(tt.after, "after")
+
+			rows := make(map[string]*RowStatus)
+			for _, row := range results.Rows() {
+				rows[row.Name] = row
+			}
+			for name, want := range tt.renamed {
+				row, ok := rows[name]
+				if !ok {
+					t.Fatalf("no row %q; rows: %v", name, rowNames(rows))
+				}
+				if row.RenamedFrom != want.from || row.MatchedBy != want.by {
+					t.Errorf("%s: renamed from %q by %q, want %q by %q", name, row.RenamedFrom, row.MatchedBy, want.from, want.by)
+				}
+				if status := row.Status(); status != "all_passed" {
+					t.Errorf("%s: status %s, want all_passed", name, status)
+				}
+				if _, ok := rows[want.from]; ok {
+					t.Errorf("old row %q still listed", want.from)
+				}
+			}
+			for _, name := range tt.missing {
+				row, ok := rows[name]
+				if !ok {
+					t.Fatalf("no row %q; rows: %v", name, rowNames(rows))
+				}
+				if row.RenamedFrom != "" || row.Status() != "missing" {
+					t.Errorf("%s: status %s, renamed from %q; want missing, not renamed", name, row.Status(), row.RenamedFrom)
+				}
+			}
+			for name, row := range rows {
+				if _, ok := tt.renamed[name]; !ok && row.RenamedFrom != "" {
+					t.Errorf("%s: unexpectedly renamed from %q", name, row.RenamedFrom)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchRenamedKeepsFirstName(t *testing.T) {
+	results := NewDiffResults()
+	results.AddParsedOutput(identityRobot(identitySuite("Login", "/t/login.robot", "Valid Login")), "1")
+	results.AddParsedOutput(identityRobot(identitySuite("Auth", "/t/login.robot", "Valid Login")), "2")
+	results.AddParsedOutput(identityRobot(identitySuite("Auth", "/t/login.robot", "Valid Login Works")), "3")
+
+	rows := results.Rows()
+	var tests []*RowStatus
+	for _, row := range rows {
+		if len(row.Path) == 3 {
+			tests = append(tests, row)
+		}
+	}
+	if len(tests) != 1 {
+		t.Fatalf("got %d test rows, want 1: %v", len(tests), rows)
+	}
+	row := tests[0]
+	if row.Name != "Tests.Auth.Valid Login Works" || row.RenamedFrom != "Tests.Login.Valid Login" {
+		t.Errorf("got %q renamed from %q", row.Name, row.RenamedFrom)
+	}
+	want := []string{"Tests.Login.Valid Login", "Tests.Auth.Valid Login", "Tests.Auth.Valid Login Works"}
+	for i, status := range row.Statuses() {
+		if status.LongName != want[i] {
+			t.Errorf("column %d: long name %q, want %q", i, status.LongName, want[i])
+		}
+	}
+}
+
+func rowNames(rows map[string]*RowStatus) []string {
+	names := make([]string, 0, len(rows))
+	for name := range rows {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Valid Login", "valid_login", 1},
+		{"Login", "Checkout", 0},
+		{"Test 1", "Test 1 Renamed", (1 + 2.0/3) / 2},
+		{"Renamed Test 1", "Test 1", (1 + 2.0/3) / 2},
+		{"Test 0", "Test 1", 0.75},
+		{"Test 1", "Test 10", 2 * 4.0 / 9},
+	}
+	for _, tt := range tests {
+		if got := nameSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("nameSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package robodiff
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Result formats recognized by DetectFormat.
+const (
+	FormatRobotXML  = "robot-xml"
+	FormatRobotJSON = "robot-json"
+	FormatJUnit     = "junit"
+)
+
+const formatProbeBytes = 64 * 1024
+
+// IsResultFileName reports whether name has an extension we may be able to
+// read. It is a cheap pre-filter; DetectFileFormat looks at the content.
+// Compressed files and archives are covered by IsArchiveFileName.
+func IsResultFileName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xml", ".json":
+		return true
+	}
+	return false
+}
+
+// DetectFormat inspects the first bytes of a file and returns its result
+// format, or "" if it is not a result file we can read.
+func DetectFormat(head []byte) string {
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	if len(trimmed) == 0 {
+		return ""
+	}
+	switch trimmed[0] {
+	case '<':
+		switch strings.ToLower(xmlRootElement(trimmed)) {
+		case "robot":
+			return FormatRobotXML
+		case "testsuites", "testsuite":
+			return FormatJUnit
+		}
+	case '{':
+		if isRobotJSONHead(trimmed) {
+			return FormatRobotJSON
+		}
+	}
+	return ""
+}
+
+// DetectFileFormat is DetectFormat for the start of the file at path.
+func DetectFileFormat(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, formatProbeBytes)
+	n, err := io.ReadFull(f, buf)
+	if n <= 0 || err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	return DetectFormat(buf[:n])
+}
+
+// xmlRootElement returns the local name of the first element in head.
+func xmlRootElement(head []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(head))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local
+		}
+	}
+}
+
+// ParseResultFile parses a result file in any supported format. path may
+// also be a gzip file, an archive holding a single result, or an archive
+// member written as "archive.zip!/dir/output.xml".
+func ParseResultFile(path string) (*Robot, error) {
+	src, err := ResolveResultSource(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResultSourceWithOptions(context.Background(), src, ParseOptions{})
+}
+
+// ParseResultFileWithOptions detects the format of path and streams it with
+// the matching parser. Files that are not recognized are parsed as XML so the
+// error explains what is wrong with them.
+func ParseResultFileWithOptions(ctx context.Context, path string, opts ParseOptions) (*Robot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseResultReader(ctx, f, detectOpenFile(f), opts)
+}
+
+// ParseResultReaderWithOptions is ParseResultFileWithOptions for a stream,
+// such as a decompressed archive member. Offsets recorded in the result are
+// relative to the start of r.
+func ParseResultReaderWithOptions(ctx context.Context, r io.Reader, opts ParseOptions) (*Robot, error) {
+	br := bufio.NewReaderSize(r, streamBufferSize)
+	head, _ := br.Peek(formatProbeBytes)
+	return parseResultReader(ctx, br, DetectFormat(head), opts)
+}
+
+func parseResultReader(ctx context.Context, r io.Reader, format string, opts ParseOptions) (*Robot, error) {
+	switch format {
+	case FormatRobotJSON:
+		return ParseRobotJSONReaderContext(ctx, r, opts)
+	case FormatJUnit:
+		// JUnit tests have no keyword bodies to trim.
+		return ParseJUnitReaderContext(ctx, r)
+	default:
+		return ParseRobotXMLReaderContext(ctx, r, opts)
+	}
+}
+
+// detectOpenFile probes f and rewinds it.
+func detectOpenFile(f *os.File) string {
+	buf := make([]byte, formatProbeBytes)
+	n, _ := io.ReadFull(f, buf)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	return DetectFormat(buf[:n])
+}
@@ -0,0 +1,805 @@
+package robodiff
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Robot Framework 7 JSON results (--output output.json). The decoder builds
+// the same Robot model as the XML parser so everything downstream works on
+// either format.
+
+type jsonStatus struct {
+	Status      string   `json:"status"`
+	Message     string   `json:"message"`
+	StartTime   string   `json:"start_time"`
+	ElapsedTime *float64 `json:"elapsed_time"`
+}
+
+func (s jsonStatus) toStatus() Status {
+	st := Status{Status: s.Status, StartTime: s.StartTime, Message: s.Message}
+	if s.ElapsedTime != nil {
+		// Six decimals, as output.xml has it.
+		st.Elapsed = strconv.FormatFloat(*s.ElapsedTime, 'f', 6, 64)
+	}
+	return st
+}
+
+type jsonTest struct {
+	jsonStatus
+	Name     string     `json:"name"`
+	Doc      string     `json:"doc"`
+	Tags     []string   `json:"tags"`
+	Timeout  string     `json:"timeout"`
+	Setup    *jsonItem  `json:"setup"`
+	Teardown *jsonItem  `json:"teardown"`
+	Body     []jsonItem `json:"body"`
+}
+
+// jsonItem is any body item. Which fields are set depends on Type; keywords
+// usually have no type at all.
+type jsonItem struct {
+	jsonStatus
+	Type     string     `json:"type"`
+	Name     string     `json:"name"`
+	Args     []string   `json:"args"`
+	Setup    *jsonItem  `json:"setup"`
+	Teardown *jsonItem  `json:"teardown"`
+	Body     []jsonItem `json:"body"`
+
+	// Keywords.
+	Owner      string   `json:"owner"`
+	SourceName string   `json:"source_name"`
+	Source     string   `json:"source"`
+	Lineno     int      `json:"lineno"`
+	Doc        string   `json:"doc"`
+	Tags       []string `json:"tags"`
+	Timeout    string   `json:"timeout"`
+
+	// FOR, WHILE, IF/TRY branches, VAR and RETURN. Assign is a list on FOR
+	// and keywords, a string on EXCEPT and a map on iterations.
+	Assign         json.RawMessage `json:"assign"`
+	Flavor         string          `json:"flavor"`
+	Values         []string        `json:"values"`
+	Condition      string          `json:"condition"`
+	Limit          string          `json:"limit"`
+	OnLimit        string          `json:"on_limit"`
+	OnLimitMessage string          `json:"on_limit_message"`
+	Patterns       []string        `json:"patterns"`
+	PatternType    string          `json:"pattern_type"`
+	Value          json.RawMessage `json:"value"`
+	Scope          string          `json:"scope"`
+	Separator      string          `json:"separator"`
+
+	// MESSAGE items carry their text in Message.
+	Level     string `json:"level"`
+	Timestamp string `json:"timestamp"`
+	HTML      bool   `json:"html"`
+}
+
+type jsonStat struct {
+	Pass     int    `json:"pass"`
+	Fail     int    `json:"fail"`
+	Skip     int    `json:"skip"`
+	Label    string `json:"label"`
+	Name     string `json:"name"`
+	ID       string `json:"id"`
+	Info     string `json:"info"`
+	Combined string `json:"combined"`
+	Doc      string `json:"doc"`
+}
+
+type jsonStatistics struct {
+	Total  json.RawMessage `json:"total"`
+	Tags   []jsonStat      `json:"tags"`
+	Suites []jsonStat      `json:"suites"`
+}
+
+func (t *jsonTest) toTest() Test {
+	test := Test{
+		Name:    t.Name,
+		Tags:    t.Tags,
+		Doc:     t.Doc,
+		Timeout: t.Timeout,
+		Status:  t.toStatus(),
+	}
+	b := convertJSONBody(withFixtures(t.Setup, t.Body, t.Teardown))
+	test.Keywords, test.Ifs, test.Fors, test.Body = b.keywords, b.ifs, b.fors, b.body
+	return test
+}
+
+// withFixtures returns body with setup and teardown as its first and last
+// keyword, which is where output.xml puts them.
+func withFixtures(setup *jsonItem, body []jsonItem, teardown *jsonItem) []jsonItem {
+	if setup == nil && teardown == nil {
+		return body
+	}
+	items := make([]jsonItem, 0, len(body)+2)
+	if setup != nil {
+		s := *setup
+		s.Type = "SETUP"
+		items = append(items, s)
+	}
+	items = append(items, body...)
+	if teardown != nil {
+		td := *teardown
+		td.Type = "TEARDOWN"
+		items = append(items, td)
+	}
+	return items
+}
+
+type jsonBody struct {
+	body     []BodyItem
+	keywords []Keyword
+	ifs      []If
+	fors     []For
+	messages []Message
+}
+
+func convertJSONBody(items []jsonItem) jsonBody {
+	var b jsonBody
+	for i := range items {
+		it := &items[i]
+		switch it.Type {
+		case "", "KEYWORD", "SETUP", "TEARDOWN":
+			kw := it.toKeyword()
+			b.keywords = append(b.keywords, kw)
+			b.body = append(b.body, BodyItem{Keyword: &kw})
+		case "FOR":
+			forblk := For{
+				Flavor: it.Flavor,
+				Var:    jsonStrings(it.Assign),
+				Value:  it.Values,
+				Iter:   convertJSONIterations(it.Body),
+				Status: it.toStatus(),
+			}
+			b.fors = append(b.fors, forblk)
+			b.body = append(b.body, BodyItem{For: &forblk})
+		case "IF/ELSE ROOT":
+			ifblk := If{Branches: convertJSONBranches(it.Body), Status: it.toStatus()}
+			b.ifs = append(b.ifs, ifblk)
+			b.body = append(b.body, BodyItem{If: &ifblk})
+		case "WHILE":
+			b.body = append(b.body, BodyItem{While: &While{
+				Condition:      it.Condition,
+				Limit:          it.Limit,
+				OnLimit:        it.OnLimit,
+				OnLimitMessage: it.OnLimitMessage,
+				Iter:           convertJSONIterations(it.Body),
+				Status:         it.toStatus(),
+			}})
+		case "TRY/EXCEPT ROOT":
+			b.body = append(b.body, BodyItem{Try: &Try{Branches: convertJSONBranches(it.Body), Status: it.toStatus()}})
+		case "BREAK":
+			b.body = append(b.body, BodyItem{Break: &Break{Status: it.toStatus()}})
+		case "CONTINUE":
+			b.body = append(b.body, BodyItem{Continue: &Continue{Status: it.toStatus()}})
+		case "VAR":
+			b.body = append(b.body, BodyItem{Var: &Var{
+				Name:      it.Name,
+				Scope:     it.Scope,
+				Separator: it.Separator,
+				Value:     jsonStrings(it.Value),
+				Status:    it.toStatus(),
+			}})
+		case "GROUP":
+			b.body = append(b.body, BodyItem{Group: &Group{
+				Name:   it.Name,
+				Status: it.toStatus(),
+				Body:   convertJSONBody(it.Body).body,
+			}})
+		case "RETURN":
+			b.body = append(b.body, BodyItem{Return: &Return{Value: it.Values, Status: it.toStatus()}})
+		case "MESSAGE":
+			b.messages = append(b.messages, Message{
+				Level:     it.Level,
+				Timestamp: it.Timestamp,
+				HTML:      it.HTML,
+				Text:      it.Message,
+			})
+		}
+	}
+	return b
+}
+
+func (it *jsonItem) toKeyword() Keyword {
+	kw := Keyword{
+		Name:       it.Name,
+		Owner:      it.Owner,
+		SourceName: it.SourceName,
+		Source:     it.Source,
+		Line:       it.Lineno,
+		Assign:     jsonStrings(it.Assign),
+		Tags:       it.Tags,
+		Doc:        it.Doc,
+		Timeout:    it.Timeout,
+		Arguments:  it.Args,
+		Status:     it.toStatus(),
+	}
+	if it.Type == "SETUP" || it.Type == "TEARDOWN" {
+		kw.Type = it.Type
+	}
+	b := convertJSONBody(withFixtures(it.Setup, it.Body, it.Teardown))
+	kw.Keywords, kw.Ifs, kw.Fors, kw.Body, kw.Messages = b.keywords, b.ifs, b.fors, b.body, b.messages
+	return kw
+}
+
+func convertJSONIterations(items []jsonItem) []Iter {
+	iters := make([]Iter, 0, len(items))
+	for i := range items {
+		if items[i].Type != "ITERATION" {
+			continue
+		}
+		b := convertJSONBody(items[i].Body)
+		iters = append(iters, Iter{
+			Keywords: b.keywords,
+			Ifs:      b.ifs,
+			Fors:     b.fors,
+			Body:     b.body,
+			Status:   items[i].toStatus(),
+		})
+	}
+	return iters
+}
+
+func convertJSONBranches(items []jsonItem) []Branch {
+	branches := make([]Branch, 0, len(items))
+	for i := range items {
+		it := &items[i]
+		if it.Type == "MESSAGE" {
+			continue
+		}
+		b := convertJSONBody(it.Body)
+		branches = append(branches, Branch{
+			Type:        it.Type,
+			Condition:   it.Condition,
+			Patterns:    it.Patterns,
+			PatternType: it.PatternType,
+			Assign:      jsonString(it.Assign),
+			Keywords:    b.keywords,
+			Ifs:         b.ifs,
+			Fors:        b.fors,
+			Body:        b.body,
+			Status:      it.toStatus(),
+		})
+	}
+	return branches
+}
+
+// jsonStrings accepts a list of strings or a single string.
+func jsonStrings(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	if s := jsonString(raw); s != "" {
+		return []string{s}
+	}
+	return nil
+}
+
+func jsonString(raw json.RawMessage) string {
+	var s string
+	if len(raw) == 0 || json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return s
+}
+
+func (s *jsonStatistics) toStatistics() *Statistics {
+	var stats []jsonStat
+	var single jsonStat
+	if err := json.Unmarshal(s.Total, &single); err == nil {
+		stats = []jsonStat{single}
+	} else if err := json.Unmarshal(s.Total, &stats); err != nil {
+		return nil
+	}
+	out := &Statistics{}
+	for _, st := range stats {
+		name := st.Label
+		if name == "" {
+			name = st.Name
+		}
+		if name == "" {
+			name = "All Tests"
+		}
+		out.Total.Stats = append(out.Total.Stats, Stat{Pass: st.Pass, Fail: st.Fail, Skip: st.Skip, Name: name})
+	}
+	for _, st := range s.Tags {
+		out.Tags = append(out.Tags, Stat{Pass: st.Pass, Fail: st.Fail, Skip: st.Skip, Name: st.Label,
+			Info: st.Info, Combined: st.Combined, Doc: st.Doc})
+	}
+	for _, st := range s.Suites {
+		out.Suites = append(out.Suites, Stat{Pass: st.Pass, Fail: st.Fail, Skip: st.Skip, Name: st.Label,
+			ID: st.ID, SuiteName: st.Name})
+	}
+	return out
+}
+
+// --- Streaming ---
+
+// ParseRobotJSONReaderContext builds a Robot from a Robot Framework JSON
+// result. Like the XML parser it decodes one test at a time, checks ctx
+// between tests and records each test's byte range for ReadJSONTestAtContext.
+func ParseRobotJSONReaderContext(ctx context.Context, r io.Reader, opts ParseOptions) (*Robot, error) {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, streamBufferSize))
+	robot := &Robot{XMLName: xml.Name{Local: "robot"}}
+	found := false
+	err := decodeJSONObject(dec, func(key string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch key {
+		case "suite":
+			suite, err := streamJSONSuite(ctx, dec, opts)
+			if err != nil {
+				return err
+			}
+			robot.Suite = suite
+			found = true
+		case "statistics":
+			var stats jsonStatistics
+			if err := dec.Decode(&stats); err != nil {
+				return err
+			}
+			robot.Statistics = stats.toStatistics()
+		case "errors":
+			msgs, err := decodeJSONErrors(dec)
+			if err != nil {
+				return err
+			}
+			robot.Errors = msgs
+		default:
+			return skipJSONValue(dec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("invalid json: missing suite")
+	}
+	return robot, nil
+}
+
+func streamJSONSuite(ctx context.Context, dec *json.Decoder, opts ParseOptions) (Suite, error) {
+	var suite Suite
+	var status jsonStatus
+	err := decodeJSONObject(dec, func(key string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch key {
+		case "name":
+			return dec.Decode(&suite.Name)
+		case "doc":
+			return dec.Decode(&suite.Doc)
+		case "source":
+			return dec.Decode(&suite.Source)
+		case "metadata":
+			return decodeJSONMetadata(dec, &suite.Metadata)
+		case "setup", "teardown":
+			var item *jsonItem
+			if err := dec.Decode(&item); err != nil || item == nil {
+				return err
+			}
+			item.Type = strings.ToUpper(key)
+			kw := item.toKeyword()
+			if key == "setup" {
+				suite.Setup = &kw
+			} else {
+				suite.Teardown = &kw
+			}
+			return nil
+		case "status":
+			return dec.Decode(&status.Status)
+		case "message":
+			return dec.Decode(&status.Message)
+		case "start_time":
+			return dec.Decode(&status.StartTime)
+		case "elapsed_time":
+			return dec.Decode(&status.ElapsedTime)
+		case "suites":
+			return decodeJSONArray(dec, func() error {
+				child, err := streamJSONSuite(ctx, dec, opts)
+				if err != nil {
+					return err
+				}
+				suite.Suites = append(suite.Suites, child)
+				return nil
+			})
+		case "tests":
+			return decodeJSONArray(dec, func() error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				offset := dec.InputOffset()
+				var jt jsonTest
+				if err := dec.Decode(&jt); err != nil {
+					return err
+				}
+				test := jt.toTest()
+				if opts.IndexOnly {
+					if len(test.Body) > 0 {
+						test.Keywords, test.Ifs, test.Fors, test.Body = nil, nil, nil, nil
+						test.BodyTruncated = true
+					}
+				} else if opts.truncates() {
+					test.truncateBody(opts)
+				}
+				test.Offset = offset
+				test.Length = dec.InputOffset() - offset
+				suite.Tests = append(suite.Tests, test)
+				return nil
+			})
+		default:
+			return skipJSONValue(dec)
+		}
+	})
+	if err != nil {
+		return Suite{}, err
+	}
+	suite.Status = status.toStatus()
+	return suite, nil
+}
+
+// decodeJSONMetadata appends a suite's metadata object to metadata, keeping
+// the keys in file order.
+func decodeJSONMetadata(dec *json.Decoder, metadata *[]Metadata) error {
+	return decodeJSONObject(dec, func(name string) error {
+		var value string
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*metadata = append(*metadata, Metadata{Name: name, Value: value})
+		return nil
+	})
+}
+
+// ReadJSONTestAtContext decodes the single test stored at
+// [offset, offset+length) in a JSON result. The range may start with the
+// separator that preceded the test in its array.
+func ReadJSONTestAtContext(ctx context.Context, r io.ReaderAt, offset, length int64) (*Test, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(io.NewSectionReader(r, offset, length), streamBufferSize)
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return nil, errors.New("invalid json: no test at recorded offset")
+		}
+		if !strings.ContainsRune(" \t\r\n,", rune(c)) {
+			_ = br.UnreadByte()
+			break
+		}
+	}
+	var jt jsonTest
+	if err := json.NewDecoder(br).Decode(&jt); err != nil {
+		return nil, err
+	}
+	test := jt.toTest()
+	test.Offset = offset
+	test.Length = length
+	return &test, nil
+}
+
+// errStopJSONWalk ends a token walk once its result is known.
+var errStopJSONWalk = errors.New("stop json walk")
+
+// FindJSONTestInReaderContext returns the test named name (dotted long name or
+// plain test name) from a JSON result, or (nil, nil) if there is none. Like
+// FindTestInReaderContext it walks the result token by token, decodes only
+// the test asked for and stops at the first test with that long name.
+func FindJSONTestInReaderContext(ctx context.Context, r io.Reader, name string) (*Test, error) {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, streamBufferSize))
+	var found, byName *Test
+	var walkSuite func(parent []string) error
+	walkSuite = func(parent []string) error {
+		// Robot writes a suite's name before its tests and child suites.
+		path := parent
+		return decodeJSONObject(dec, func(key string) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			switch key {
+			case "name":
+				var suiteName string
+				if err := dec.Decode(&suiteName); err != nil {
+					return err
+				}
+				path = append(parent[:len(parent):len(parent)], suiteName)
+				return nil
+			case "suites":
+				return decodeJSONArray(dec, func() error { return walkSuite(path) })
+			case "tests":
+				return decodeJSONArray(dec, func() error {
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+					offset := dec.InputOffset()
+					var raw json.RawMessage
+					if err := dec.Decode(&raw); err != nil {
+						return err
+					}
+					testName, err := jsonTestName(raw)
+					if err != nil {
+						return err
+					}
+					fullMatch := strings.EqualFold(LongName(append(path[:len(path):len(path)], testName)), name)
+					if !fullMatch && (byName != nil || !strings.EqualFold(testName, name)) {
+						return nil
+					}
+					var jt jsonTest
+					if err := json.Unmarshal(raw, &jt); err != nil {
+						return err
+					}
+					test := jt.toTest()
+					test.Offset = offset
+					test.Length = dec.InputOffset() - offset
+					if fullMatch {
+						found = &test
+						return errStopJSONWalk
+					}
+					byName = &test
+					return nil
+				})
+			default:
+				return skipJSONValue(dec)
+			}
+		})
+	}
+	err := decodeJSONObject(dec, func(key string) error {
+		if key != "suite" {
+			return skipJSONValue(dec)
+		}
+		return walkSuite(nil)
+	})
+	if found != nil {
+		return found, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return byName, nil
+}
+
+// jsonTestName returns the name of an encoded test, reading no further than
+// its "name" member.
+func jsonTestName(raw json.RawMessage) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	var name string
+	err := decodeJSONObject(dec, func(key string) error {
+		if key != "name" {
+			return skipJSONValue(dec)
+		}
+		if err := dec.Decode(&name); err != nil {
+			return err
+		}
+		return errStopJSONWalk
+	})
+	if err != nil && err != errStopJSONWalk {
+		return "", err
+	}
+	return name, nil
+}
+
+// findTestInRobot looks a test up by dotted long name, falling back to the
+// first test with that plain name. Both comparisons ignore case.
+func findTestInRobot(robot *Robot, name string) *Test {
+	var byName *Test
+	var walk func(s *Suite, prefix string) *Test
+	walk = func(s *Suite, prefix string) *Test {
+		long := s.Name
+		if prefix != "" {
+			long = prefix + "." + s.Name
+		}
+		for i := range s.Tests {
+			t := &s.Tests[i]
+			if strings.EqualFold(long+"."+t.Name, name) {
+				return t
+			}
+			if byName == nil && strings.EqualFold(t.Name, name) {
+				byName = t
+			}
+		}
+		for i := range s.Suites {
+			if t := walk(&s.Suites[i], long); t != nil {
+				return t
+			}
+		}
+		return nil
+	}
+	if t := walk(&robot.Suite, ""); t != nil {
+		return t
+	}
+	return byName
+}
+
+// DecodeRobotJSONStatistics decodes the value of the "statistics" member of a
+// JSON result from r. Trailing data after the value is ignored.
+func DecodeRobotJSONStatistics(r io.Reader) (*Statistics, error) {
+	var stats jsonStatistics
+	if err := json.NewDecoder(r).Decode(&stats); err != nil {
+		return nil, err
+	}
+	out := stats.toStatistics()
+	if out == nil {
+		return nil, errors.New("invalid json: unexpected statistics total")
+	}
+	return out, nil
+}
+
+// ResultSummary is what ScanRobotJSONSummary reads from a result: its
+// statistics (nil if absent), the status and metadata of the root suite and
+// the execution errors.
+type ResultSummary struct {
+	Statistics *Statistics
+	Status     Status
+	Metadata   []Metadata
+	Errors     []Message
+}
+
+// ScanRobotJSONSummary walks a JSON result without decoding any tests and
+// returns its summary.
+func ScanRobotJSONSummary(ctx context.Context, r io.Reader) (*ResultSummary, error) {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, streamBufferSize))
+	var summary ResultSummary
+	var status jsonStatus
+	err := decodeJSONObject(dec, func(key string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch key {
+		case "suite":
+			return decodeJSONObject(dec, func(key string) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				switch key {
+				case "status":
+					return dec.Decode(&status.Status)
+				case "start_time":
+					return dec.Decode(&status.StartTime)
+				case "elapsed_time":
+					return dec.Decode(&status.ElapsedTime)
+				case "metadata":
+					return decodeJSONMetadata(dec, &summary.Metadata)
+				default:
+					return skipJSONValue(dec)
+				}
+			})
+		case "statistics":
+			var s jsonStatistics
+			if err := dec.Decode(&s); err != nil {
+				return err
+			}
+			summary.Statistics = s.toStatistics()
+			return nil
+		case "errors":
+			msgs, err := decodeJSONErrors(dec)
+			if err != nil {
+				return err
+			}
+			summary.Errors = msgs
+			return nil
+		default:
+			return skipJSONValue(dec)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	summary.Status = status.toStatus()
+	return &summary, nil
+}
+
+// --- Token helpers ---
+
+func decodeJSONObject(dec *json.Decoder, member func(key string) error) error {
+	if err := expectJSONDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("invalid json: unexpected %v", tok)
+		}
+		if err := member(key); err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(dec, '}')
+}
+
+func decodeJSONArray(dec *json.Decoder, element func() error) error {
+	if err := expectJSONDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := element(); err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(dec, ']')
+}
+
+func expectJSONDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("invalid json: expected %v, got %v", want, tok)
+	}
+	return nil
+}
+
+// skipJSONValue consumes the next value token by token so skipped subtrees
+// are never held in memory.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// isRobotJSONHead reports whether head starts a Robot Framework JSON result:
+// an object whose "generator" names Robot or Rebot, or which has a "suite"
+// before any generator.
+func isRobotJSONHead(head []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(head))
+	if expectJSONDelim(dec, '{') != nil {
+		return false
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		switch tok {
+		case "generator":
+			var generator string
+			if dec.Decode(&generator) != nil {
+				return false
+			}
+			lower := strings.ToLower(generator)
+			return strings.HasPrefix(lower, "robot") || strings.HasPrefix(lower, "rebot")
+		case "suite":
+			return true
+		}
+		if skipJSONValue(dec) != nil {
+			return false
+		}
+	}
+	return false
+}
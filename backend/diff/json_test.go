@@ -0,0 +1,174 @@
+package robodiff
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testdata/output.xml and testdata/output.json hold the same run, written
+// the way Robot Framework 7 writes each format.
+const (
+	fixtureXML  = "testdata/output.xml"
+	fixtureJSON = "testdata/output.json"
+)
+
+func TestParseRobotJSONMatchesXML(t *testing.T) {
+	ctx := context.Background()
+	fromXML, err := ParseResultFileWithOptions(ctx, fixtureXML, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ParseResultFileWithOptions(ctx, fixtureJSON, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Offsets point into each file; check that they lead back to the same
+	// test, then compare the trees without them.
+	checkOffsets(t, fixtureXML, fromXML)
+	checkOffsets(t, fixtureJSON, fromJSON)
+	clearOffsets(&fromXML.Suite)
+	clearOffsets(&fromJSON.Suite)
+
+	if !reflect.DeepEqual(fromJSON.Statistics, fromXML.Statistics) {
+		t.Errorf("statistics differ:\njson %+v\n xml %+v", fromJSON.Statistics, fromXML.Statistics)
+	}
+	if !reflect.DeepEqual(fromJSON.Errors, fromXML.Errors) {
+		t.Errorf("errors differ:\njson %+v\n xml %+v", fromJSON.Errors, fromXML.Errors)
+	}
+	compareSuites(t, &fromJSON.Suite, &fromXML.Suite)
+	if !t.Failed() && !reflect.DeepEqual(fromJSON, fromXML) {
+		t.Errorf("robots differ:\njson %+v\n xml %+v", fromJSON, fromXML)
+	}
+
+	if got := len(fromJSON.Errors); got != 2 {
+		t.Errorf("got %d execution errors, want 2", got)
+	}
+	valid := &fromJSON.Suite.Suites[0].Tests[0]
+	var kinds []string
+	for _, item := range valid.Body {
+		switch {
+		case item.Keyword != nil:
+			kinds = append(kinds, "kw "+item.Keyword.Name)
+		case item.For != nil:
+			kinds = append(kinds, "for")
+		case item.If != nil:
+			kinds = append(kinds, "if")
+		}
+	}
+	if want := []string{"kw Input Credentials", "for", "if"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("body order %v, want %v", kinds, want)
+	}
+	invalid := &fromJSON.Suite.Suites[0].Tests[1]
+	if last := invalid.Body[len(invalid.Body)-1].Keyword; last == nil || last.Type != "TEARDOWN" {
+		t.Errorf("teardown not last in body: %+v", invalid.Body)
+	}
+}
+
+func TestFindJSONTestInReader(t *testing.T) {
+	ctx := context.Background()
+	robot, err := ParseResultFileWithOptions(ctx, fixtureJSON, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		want *Test
+	}{
+		{"Fixture Run.Login.Invalid Password", &robot.Suite.Suites[0].Tests[1]},
+		{"fixture run.reports.export pdf", &robot.Suite.Suites[1].Tests[0]},
+		{"Valid Login", &robot.Suite.Suites[0].Tests[0]},
+		{"Fixture Run.Reports.Valid Login", nil},
+		{"Missing", nil},
+	}
+	for _, tt := range tests {
+		got, err := FindTestInFileContext(ctx, fixtureJSON, tt.name)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if tt.want == nil {
+			if got != nil {
+				t.Errorf("%s: found %q, want none", tt.name, got.Name)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// A truncated file fails only if the test is not found first.
+	data := `{"suite":{"name":"S","tests":[{"name":"A","status":"PASS"},{"name":"B","sta`
+	if got, err := FindJSONTestInReaderContext(ctx, strings.NewReader(data), "S.A"); err != nil || got == nil || got.Name != "A" {
+		t.Errorf("S.A in truncated file: %+v, %v", got, err)
+	}
+	if _, err := FindJSONTestInReaderContext(ctx, strings.NewReader(data), "S.B"); err == nil {
+		t.Errorf("S.B in truncated file: no error")
+	}
+}
+
+// checkOffsets reads every test of robot back from path by its recorded
+// offset.
+func checkOffsets(t *testing.T, path string, robot *Robot) {
+	t.Helper()
+	var walk func(s *Suite)
+	walk = func(s *Suite) {
+		for i := range s.Tests {
+			test := &s.Tests[i]
+			if test.Length == 0 {
+				t.Errorf("%s: %s has no recorded range", path, test.Name)
+				continue
+			}
+			got, err := ReadTestAtFileContext(context.Background(), path, test.Offset, test.Length)
+			if err != nil {
+				t.Errorf("%s: reading %s at %d: %v", path, test.Name, test.Offset, err)
+				continue
+			}
+			got.Offset, got.Length = test.Offset, test.Length
+			if !reflect.DeepEqual(got, test) {
+				t.Errorf("%s: %s read at its offset differs:\n got %+v\nwant %+v", path, test.Name, got, test)
+			}
+		}
+		for i := range s.Suites {
+			walk(&s.Suites[i])
+		}
+	}
+	walk(&robot.Suite)
+}
+
+func clearOffsets(s *Suite) {
+	for i := range s.Tests {
+		s.Tests[i].Offset, s.Tests[i].Length = 0, 0
+	}
+	for i := range s.Suites {
+		clearOffsets(&s.Suites[i])
+	}
+}
+
+// compareSuites reports the first differing suite header or test so a
+// failure points at what the decoders disagree on.
+func compareSuites(t *testing.T, got, want *Suite) {
+	t.Helper()
+	header := func(s *Suite) Suite {
+		return Suite{Name: s.Name, Source: s.Source, Doc: s.Doc, Metadata: s.Metadata,
+			Setup: s.Setup, Teardown: s.Teardown, Status: s.Status}
+	}
+	if g, w := header(got), header(want); !reflect.DeepEqual(g, w) {
+		t.Errorf("suite %s differs:\njson %+v\n xml %+v", want.Name, g, w)
+	}
+	if len(got.Tests) != len(want.Tests) || len(got.Suites) != len(want.Suites) {
+		t.Errorf("suite %s: json has %d tests and %d suites, xml %d and %d", want.Name,
+			len(got.Tests), len(got.Suites), len(want.Tests), len(want.Suites))
+		return
+	}
+	for i := range want.Tests {
+		if !reflect.DeepEqual(got.Tests[i], want.Tests[i]) {
+			t.Errorf("test %s differs:\njson %+v\n xml %+v", want.Tests[i].Name, got.Tests[i], want.Tests[i])
+		}
+	}
+	for i := range want.Suites {
+		compareSuites(t, &got.Suites[i], &want.Suites[i])
+	}
+}
@@ -0,0 +1,219 @@
+package robodiff
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JUnit/xUnit results (<testsuites>/<testsuite>/<testcase>), as written by
+// `robot --xunit`, pytest and the usual Go test converters. They have no
+// keywords, so each test gets at most a pseudo-keyword holding the failure
+// details and captured output.
+
+type junitCase struct {
+	Name      string      `xml:"name,attr"`
+	Classname string      `xml:"classname,attr"`
+	Time      string      `xml:"time,attr"`
+	Failure   *junitIssue `xml:"failure"`
+	Error     *junitIssue `xml:"error"`
+	Skipped   *junitIssue `xml:"skipped"`
+	SystemOut string      `xml:"system-out"`
+	SystemErr string      `xml:"system-err"`
+}
+
+type junitIssue struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnitReaderContext builds a Robot from a JUnit/xUnit file. The suite
+// tree comes from each test's classname (dotted, like a Robot long name) and
+// falls back to the enclosing <testsuite> names, so `robot --xunit` output
+// lines up with the output.xml of the same run. Suite statuses are derived
+// from their tests.
+func ParseJUnitReaderContext(ctx context.Context, r io.Reader) (*Robot, error) {
+	d := xml.NewDecoder(bufio.NewReaderSize(r, streamBufferSize))
+	root := &Suite{}
+	var suites []string
+	var rootName, startTime string
+	sawRoot := false
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		switch se := tok.(type) {
+		case xml.StartElement:
+			switch se.Name.Local {
+			case "testsuites":
+				sawRoot = true
+				rootName = attrValue(se, "name")
+			case "testsuite":
+				sawRoot = true
+				if len(suites) == 0 {
+					if rootName == "" {
+						rootName = attrValue(se, "name")
+					}
+					if startTime == "" {
+						startTime = attrValue(se, "timestamp")
+					}
+				}
+				suites = append(suites, attrValue(se, "name"))
+			case "testcase":
+				var tc junitCase
+				if err := d.DecodeElement(&tc, &se); err != nil {
+					return nil, err
+				}
+				path := suites
+				if tc.Classname != "" {
+					path = strings.Split(tc.Classname, ".")
+				}
+				suite := junitSuiteAt(root, path)
+				suite.Tests = append(suite.Tests, tc.toTest())
+			default:
+				if !sawRoot {
+					return nil, errors.New("invalid xml: root element is not <testsuites> or <testsuite>")
+				}
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if se.Name.Local == "testsuite" && len(suites) > 0 {
+				suites = suites[:len(suites)-1]
+			}
+		}
+	}
+	if !sawRoot {
+		return nil, errors.New("invalid xml: missing <testsuites> element")
+	}
+
+	// A single top-level suite becomes the root, like Robot's own top suite.
+	if len(root.Tests) == 0 && len(root.Suites) == 1 {
+		root = &root.Suites[0]
+	} else {
+		root.Name = rootName
+		if root.Name == "" {
+			root.Name = "Tests"
+		}
+	}
+	junitFinishSuite(root)
+	if root.Status.StartTime == "" {
+		root.Status.StartTime = startTime
+	}
+	return &Robot{XMLName: xml.Name{Local: "robot"}, Suite: *root}, nil
+}
+
+// junitSuiteAt returns the suite at path below root, creating it as needed.
+func junitSuiteAt(root *Suite, path []string) *Suite {
+	suite := root
+	for _, name := range path {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var child *Suite
+		for i := range suite.Suites {
+			if suite.Suites[i].Name == name {
+				child = &suite.Suites[i]
+				break
+			}
+		}
+		if child == nil {
+			suite.Suites = append(suite.Suites, Suite{Name: name})
+			child = &suite.Suites[len(suite.Suites)-1]
+		}
+		suite = child
+	}
+	return suite
+}
+
+// junitFinishSuite fills in suite statuses and elapsed times bottom-up:
+// FAIL if anything failed, PASS if anything passed, SKIP otherwise.
+func junitFinishSuite(suite *Suite) (elapsed float64) {
+	var pass, fail bool
+	note := func(status string, seconds float64) {
+		switch status {
+		case "FAIL":
+			fail = true
+		case "PASS":
+			pass = true
+		}
+		elapsed += seconds
+	}
+	for i := range suite.Suites {
+		child := &suite.Suites[i]
+		note(child.Status.Status, junitFinishSuite(child))
+	}
+	for _, t := range suite.Tests {
+		seconds, _ := strconv.ParseFloat(t.Status.Elapsed, 64)
+		note(t.Status.Status, seconds)
+	}
+	switch {
+	case fail:
+		suite.Status.Status = "FAIL"
+	case pass:
+		suite.Status.Status = "PASS"
+	default:
+		suite.Status.Status = "SKIP"
+	}
+	suite.Status.Elapsed = strconv.FormatFloat(elapsed, 'f', -1, 64)
+	return elapsed
+}
+
+func (tc *junitCase) toTest() Test {
+	test := Test{Name: tc.Name, Status: Status{Status: "PASS", Elapsed: strings.TrimSpace(tc.Time)}}
+	issue, status, kind := tc.Failure, "FAIL", "failure"
+	switch {
+	case tc.Failure != nil:
+	case tc.Error != nil:
+		issue, kind = tc.Error, "error"
+	case tc.Skipped != nil:
+		issue, status, kind = tc.Skipped, "SKIP", "skipped"
+	}
+	// Passing tests keep their captured output too, under an "output"
+	// keyword that is only added when there is some.
+	kw := Keyword{Name: "output", Type: "OUTPUT", Status: Status{Status: "PASS"}}
+	if issue != nil {
+		test.Status.Status = status
+		details := strings.TrimSpace(issue.Text)
+		test.Status.Message = strings.TrimSpace(issue.Message)
+		if test.Status.Message == "" {
+			test.Status.Message = details
+		}
+
+		name := kind
+		if issue.Type != "" {
+			name = kind + " " + issue.Type
+		}
+		kw = Keyword{Name: name, Type: strings.ToUpper(kind), Status: Status{Status: status, Message: test.Status.Message}}
+		if details != "" && details != test.Status.Message {
+			kw.Messages = append(kw.Messages, Message{Level: status, Text: details})
+		}
+	}
+	if out := strings.TrimSpace(tc.SystemOut); out != "" {
+		kw.Messages = append(kw.Messages, Message{Level: "INFO", Text: out})
+	}
+	if errOut := strings.TrimSpace(tc.SystemErr); errOut != "" {
+		kw.Messages = append(kw.Messages, Message{Level: "WARN", Text: errOut})
+	}
+	if issue == nil && len(kw.Messages) == 0 {
+		return test
+	}
+	test.Keywords = []Keyword{kw}
+	test.Body = []BodyItem{{Keyword: &kw}}
+	return test
+}
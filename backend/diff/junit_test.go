@@ -0,0 +1,62 @@
+package robodiff
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJUnitOutput(t *testing.T) {
+	data := `<testsuite name="suite">
+<testcase classname="Tests.Login" name="Quiet" time="0.1"/>
+<testcase classname="Tests.Login" name="Chatty" time="0.2"><system-out>connected
+</system-out><system-err>slow response</system-err></testcase>
+<testcase classname="Tests.Login" name="Broken" time="0.3"><failure message="boom" type="AssertionError">trace</failure><system-out>log line</system-out></testcase>
+</testsuite>`
+	robot, err := ParseJUnitReaderContext(context.Background(), strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		status string
+		kw     string
+		msgs   []Message
+	}{
+		{"Tests.Login.Quiet", "PASS", "", nil},
+		{"Tests.Login.Chatty", "PASS", "output", []Message{
+			{Level: "INFO", Text: "connected"},
+			{Level: "WARN", Text: "slow response"},
+		}},
+		{"Tests.Login.Broken", "FAIL", "failure AssertionError", []Message{
+			{Level: "FAIL", Text: "trace"},
+			{Level: "INFO", Text: "log line"},
+		}},
+	}
+	for _, tt := range tests {
+		test := findTestInRobot(robot, tt.name)
+		if test == nil {
+			t.Errorf("no test %s", tt.name)
+			continue
+		}
+		if test.Status.Status != tt.status {
+			t.Errorf("%s: status %s, want %s", tt.name, test.Status.Status, tt.status)
+		}
+		if tt.kw == "" {
+			if len(test.Keywords) != 0 || len(test.Body) != 0 {
+				t.Errorf("%s: keywords %+v, want none", tt.name, test.Keywords)
+			}
+			continue
+		}
+		if len(test.Keywords) != 1 || len(test.Body) != 1 || test.Body[0].Keyword == nil {
+			t.Errorf("%s: keywords %+v, want one", tt.name, test.Keywords)
+			continue
+		}
+		kw := test.Keywords[0]
+		if kw.Name != tt.kw || kw.Status.Status != tt.status || !reflect.DeepEqual(kw.Messages, tt.msgs) {
+			t.Errorf("%s: keyword %q %s with %+v, want %q %s with %+v",
+				tt.name, kw.Name, kw.Status.Status, kw.Messages, tt.kw, tt.status, tt.msgs)
+		}
+	}
+}
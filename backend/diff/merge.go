@@ -0,0 +1,351 @@
+package robodiff
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Which status of a re-executed test a diff compares (see FirstAttempts).
+const (
+	AttemptFinal = "final"
+	AttemptFirst = "first"
+)
+
+// MergeRobots combines results the way `rebot --merge` does: suites are
+// matched by name, a test that appears again replaces the earlier result and
+// new tests and suites are added. This covers both pabot workers, which each
+// hold part of the suites, and `--rerunfailed` outputs, which are passed after
+// the original. A replaced test remembers the status of its first run in
+// FirstAttempt, and every test records in Input which of robots it came from.
+//
+// As in rebot, a rerun that was skipped does not replace an earlier result
+// that was not. Root suites with different names are kept side by side under
+// a combined root. Suite statuses and times are recomputed and statistics are
+// dropped because they no longer describe the merged tree; execution errors
+// of all inputs are kept. The inputs are not modified.
+func MergeRobots(robots ...*Robot) *Robot {
+	return MergeRobotsWithOptions(MergeOptions{}, robots...)
+}
+
+// MergeOptions tunes MergeRobotsWithOptions.
+type MergeOptions struct {
+	// Notes rewrites the message of each re-executed test into rebot's HTML
+	// note giving the new and the old status and message.
+	Notes bool
+}
+
+// MergeRobotsWithOptions is MergeRobots with options.
+func MergeRobotsWithOptions(opts MergeOptions, robots ...*Robot) *Robot {
+	var out *Robot
+	combined := false
+	for i, robot := range robots {
+		if robot == nil {
+			continue
+		}
+		src := cloneSuite(&robot.Suite, i)
+		if out == nil {
+			out = &Robot{XMLName: robot.XMLName, Suite: src}
+			out.Errors = append(out.Errors, robot.Errors...)
+			continue
+		}
+		out.Errors = append(out.Errors, robot.Errors...)
+		switch {
+		case combined:
+			mergeChildSuite(&out.Suite, src, opts)
+		case out.Suite.Name == src.Name:
+			mergeSuite(&out.Suite, &src, opts)
+		default:
+			root := out.Suite
+			out.Suite = Suite{Name: root.Name + " & " + src.Name, Suites: []Suite{root}, Status: root.Status}
+			mergeChildSuite(&out.Suite, src, opts)
+			combined = true
+		}
+	}
+	if out == nil {
+		return &Robot{}
+	}
+	finishMergedSuite(&out.Suite)
+	return out
+}
+
+// cloneSuite copies the suite tree deeply enough that merging into it never
+// touches the input, tagging each test with its input index.
+func cloneSuite(suite *Suite, input int) Suite {
+	out := *suite
+	out.Tests = make([]Test, len(suite.Tests))
+	for i, test := range suite.Tests {
+		test.Input = input
+		out.Tests[i] = test
+	}
+	out.Suites = make([]Suite, len(suite.Suites))
+	for i := range suite.Suites {
+		out.Suites[i] = cloneSuite(&suite.Suites[i], input)
+	}
+	return out
+}
+
+func mergeSuite(dst, src *Suite, opts MergeOptions) {
+	mergeStatusTimes(&dst.Status, src.Status)
+	mergeSuiteHeader(dst, src)
+	for _, test := range src.Tests {
+		replaced := false
+		for i := range dst.Tests {
+			if dst.Tests[i].Name != test.Name {
+				continue
+			}
+			mergeTest(&dst.Tests[i], test, opts)
+			replaced = true
+			break
+		}
+		if !replaced {
+			dst.Tests = append(dst.Tests, test)
+		}
+	}
+	for _, child := range src.Suites {
+		mergeChildSuite(dst, child, opts)
+	}
+}
+
+func mergeChildSuite(parent *Suite, child Suite, opts MergeOptions) {
+	for i := range parent.Suites {
+		if parent.Suites[i].Name == child.Name {
+			mergeSuite(&parent.Suites[i], &child, opts)
+			return
+		}
+	}
+	parent.Suites = append(parent.Suites, child)
+}
+
+// mergeSuiteHeader takes the documentation, metadata and fixtures of the
+// later execution of a suite, keeping earlier values it does not set.
+func mergeSuiteHeader(dst, src *Suite) {
+	if dst.Source == "" {
+		dst.Source = src.Source
+	}
+	if src.Doc != "" {
+		dst.Doc = src.Doc
+	}
+	if src.Setup != nil {
+		dst.Setup = src.Setup
+	}
+	if src.Teardown != nil {
+		dst.Teardown = src.Teardown
+	}
+	if len(src.Metadata) == 0 {
+		return
+	}
+	metadata := append([]Metadata(nil), dst.Metadata...)
+	for _, meta := range src.Metadata {
+		replaced := false
+		for i := range metadata {
+			if metadata[i].Name == meta.Name {
+				metadata[i].Value = meta.Value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			metadata = append(metadata, meta)
+		}
+	}
+	dst.Metadata = metadata
+}
+
+// mergeTest replaces old with its re-execution.
+func mergeTest(old *Test, rerun Test, opts MergeOptions) {
+	if NormalizeStatus(rerun.Status.Status) == "SKIP" && NormalizeStatus(old.Status.Status) != "SKIP" {
+		if opts.Notes {
+			old.Status.Message = skippedRerunNote(rerun.Status)
+		}
+		return
+	}
+	first := old.FirstAttempt
+	if first == nil {
+		status := old.Status
+		first = &status
+	}
+	if opts.Notes {
+		rerun.Status.Message = mergeNote(rerun.Status, old.Status)
+	}
+	rerun.FirstAttempt = first
+	*old = rerun
+}
+
+// mergeNote builds rebot's message for a merged test.
+func mergeNote(rerun, old Status) string {
+	return strings.Join([]string{
+		`*HTML* <span class="merge">Re-executed test has been merged.</span>`,
+		noteStatusAndMessage("New", rerun),
+		noteStatusAndMessage("Old", old),
+	}, "<hr>")
+}
+
+func skippedRerunNote(rerun Status) string {
+	note := `*HTML* <span class="merge">Test has been re-executed and results merged. ` +
+		`Latter result had <span class="skip">SKIP</span> status and was ignored.</span>`
+	if msg := noteMessage(rerun.Message); msg != "" {
+		note += "<hr>Message: " + msg
+	}
+	return note
+}
+
+func noteStatusAndMessage(state string, status Status) string {
+	lower := strings.ToLower(state)
+	note := fmt.Sprintf(`<span class="%s-status">%s status:</span> <span class="%s">%s</span>`,
+		lower, state, strings.ToLower(status.Status), html.EscapeString(status.Status))
+	if msg := noteMessage(status.Message); msg != "" {
+		note += fmt.Sprintf(`<br><span class="%s-message">%s message:</span> %s`, lower, state, msg)
+	}
+	return note
+}
+
+// noteMessage embeds a test message in an HTML note: *HTML* messages as is,
+// others escaped.
+func noteMessage(msg string) string {
+	msg = strings.TrimSpace(msg)
+	if rest, ok := strings.CutPrefix(msg, "*HTML*"); ok {
+		return strings.TrimSpace(rest)
+	}
+	return html.EscapeString(msg)
+}
+
+// finishMergedSuite recomputes suite statuses bottom-up: FAIL if anything
+// failed, PASS if anything passed, SKIP otherwise.
+func finishMergedSuite(suite *Suite) string {
+	var pass, fail bool
+	note := func(status string) {
+		switch NormalizeStatus(status) {
+		case "FAIL":
+			fail = true
+		case "PASS":
+			pass = true
+		}
+	}
+	for i := range suite.Suites {
+		note(finishMergedSuite(&suite.Suites[i]))
+	}
+	for _, test := range suite.Tests {
+		note(test.Status.Status)
+	}
+	switch {
+	case fail:
+		suite.Status.Status = "FAIL"
+	case pass:
+		suite.Status.Status = "PASS"
+	default:
+		suite.Status.Status = "SKIP"
+	}
+	return suite.Status.Status
+}
+
+// mergeStatusTimes widens dst to also cover src. Times are kept in the
+// format they were read in; statuses without parseable times are left alone.
+func mergeStatusTimes(dst *Status, src Status) {
+	dstStart, dstEnd, ok := statusSpan(*dst)
+	srcStart, srcEnd, srcOK := statusSpan(src)
+	if !ok || !srcOK {
+		return
+	}
+	if srcStart.Before(dstStart) {
+		dst.StartTime = src.StartTime
+		dstStart = srcStart
+	}
+	if srcEnd.After(dstEnd) {
+		if dst.EndTime != "" && src.EndTime != "" {
+			dst.EndTime = src.EndTime
+		}
+		dstEnd = srcEnd
+	}
+	if dst.Elapsed != "" {
+		dst.Elapsed = strconv.FormatFloat(dstEnd.Sub(dstStart).Seconds(), 'f', 6, 64)
+	}
+}
+
+// statusSpan returns the start and end of a status, taking the end from the
+// elapsed time when there is no end time (Robot Framework 7).
+func statusSpan(status Status) (start, end time.Time, ok bool) {
+	start, ok = ParseRobotTime(status.StartTime)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	if end, ok := ParseRobotTime(status.EndTime); ok {
+		return start, end, true
+	}
+	elapsed, err := strconv.ParseFloat(strings.TrimSpace(status.Elapsed), 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(time.Duration(elapsed * float64(time.Second))), true
+}
+
+// robotTimeLayouts are the timestamp formats of Robot results: RF 7 ISO times,
+// with or without a zone, and the older "20240502 10:00:00.000".
+var robotTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000000",
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	"20060102 15:04:05.000",
+	"20060102 15:04:05",
+}
+
+// ParseRobotTime parses a start or end time of a Robot result in local time.
+// Empty values and "N/A" are not times.
+func ParseRobotTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "N/A" {
+		return time.Time{}, false
+	}
+	for _, layout := range robotTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FirstAttempts returns a copy of robot in which re-executed tests carry the
+// status of their first attempt instead of the final one. Robots without
+// merged reruns are returned as is.
+func FirstAttempts(robot *Robot) *Robot {
+	if robot == nil || !hasFirstAttempts(&robot.Suite) {
+		return robot
+	}
+	out := *robot
+	out.Statistics = nil
+	out.Suite = firstAttemptSuite(&robot.Suite)
+	return &out
+}
+
+func hasFirstAttempts(suite *Suite) bool {
+	for i := range suite.Tests {
+		if suite.Tests[i].FirstAttempt != nil {
+			return true
+		}
+	}
+	for i := range suite.Suites {
+		if hasFirstAttempts(&suite.Suites[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstAttemptSuite(suite *Suite) Suite {
+	out := *suite
+	out.Tests = make([]Test, len(suite.Tests))
+	for i, test := range suite.Tests {
+		if test.FirstAttempt != nil {
+			test.Status = *test.FirstAttempt
+		}
+		out.Tests[i] = test
+	}
+	out.Suites = make([]Suite, len(suite.Suites))
+	for i := range suite.Suites {
+		out.Suites[i] = firstAttemptSuite(&suite.Suites[i])
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package robodiff
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mergeInput is a result with one suite "Login" below root, holding tests
+// given as name/status pairs, all tagged "login".
+func mergeInput(root string, tests ...string) *Robot {
+	suite := Suite{Name: "Login", Source: "/t/login.robot"}
+	for i := 0; i+1 < len(tests); i += 2 {
+		suite.Tests = append(suite.Tests, Test{
+			Name:   tests[i],
+			Tags:   []string{"login"},
+			Status: Status{Status: tests[i+1], Message: tests[i] + " " + tests[i+1]},
+		})
+	}
+	robot := &Robot{Suite: Suite{Name: root, Suites: []Suite{suite}}}
+	robot.Statistics = ComputeStatistics(&robot.Suite)
+	return robot
+}
+
+// mergedTest returns the test at the dotted long name below the merged root.
+func mergedTest(t *testing.T, robot *Robot, longName string) *Test {
+	t.Helper()
+	if test := findTestInRobot(robot, longName); test != nil {
+		return test
+	}
+	t.Fatalf("no test %s", longName)
+	return nil
+}
+
+func TestMergeRobots(t *testing.T) {
+	// mergedStatus is the final status of a test, that of its first attempt
+	// ("" for none) and the input the result came from.
+	type mergedStatus struct {
+		status, first string
+		input         int
+	}
+	tests := []struct {
+		name   string
+		inputs []*Robot
+		want   map[string]mergedStatus
+	}{
+		{
+			name: "later result replaces earlier",
+			inputs: []*Robot{
+				mergeInput("Tests", "A", "FAIL", "B", "PASS"),
+				mergeInput("Tests", "A", "PASS"),
+			},
+			want: map[string]mergedStatus{
+				"Tests.Login.A": {"PASS", "FAIL", 1},
+				"Tests.Login.B": {"PASS", "", 0},
+			},
+		},
+		{
+			name: "skipped rerun keeps the result that ran",
+			inputs: []*Robot{
+				mergeInput("Tests", "A", "FAIL", "B", "SKIP"),
+				mergeInput("Tests", "A", "SKIP", "B", "PASS"),
+			},
+			want: map[string]mergedStatus{
+				"Tests.Login.A": {"FAIL", "", 0},
+				"Tests.Login.B": {"PASS", "SKIP", 1},
+			},
+		},
+		{
+			name: "first attempt kept across three inputs",
+			inputs: []*Robot{
+				mergeInput("Tests", "A", "FAIL"),
+				mergeInput("Tests", "A", "FAIL"),
+				mergeInput("Tests", "A", "PASS"),
+			},
+			want: map[string]mergedStatus{
+				"Tests.Login.A": {"PASS", "FAIL", 2},
+			},
+		},
+		{
+			name: "new tests are added",
+			inputs: []*Robot{
+				mergeInput("Tests", "A", "PASS"),
+				mergeInput("Tests", "B", "FAIL"),
+			},
+			want: map[string]mergedStatus{
+				"Tests.Login.A": {"PASS", "", 0},
+				"Tests.Login.B": {"FAIL", "", 1},
+			},
+		},
+		{
+			name: "differently named roots are combined",
+			inputs: []*Robot{
+				mergeInput("Worker 1", "A", "PASS"),
+				mergeInput("Worker 2", "B", "FAIL"),
+				mergeInput("Worker 1", "A", "FAIL"),
+			},
+			want: map[string]mergedStatus{
+				"Worker 1 & Worker 2.Worker 1.Login.A": {"FAIL", "PASS", 2},
+				"Worker 1 & Worker 2.Worker 2.Login.B": {"FAIL", "", 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := MergeRobots(tt.inputs...)
+			if _, _, _, total := CountTests(&merged.Suite); total != len(tt.want) {
+				t.Errorf("merged %d tests, want %d", total, len(tt.want))
+			}
+			for name, want := range tt.want {
+				test := mergedTest(t, merged, name)
+				first := ""
+				if test.FirstAttempt != nil {
+					first = test.FirstAttempt.Status
+				}
+				if test.Status.Status != want.status || first != want.first || test.Input != want.input {
+					t.Errorf("%s: status %s, first attempt %q, input %d; want %s, %q, %d",
+						name, test.Status.Status, first, test.Input, want.status, want.first, want.input)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeRobotsFirstAttemptMessage(t *testing.T) {
+	merged := MergeRobots(
+		mergeInput("Tests", "A", "FAIL"),
+		mergeInput("Tests", "A", "SKIP"),
+		mergeInput("Tests", "A", "FAIL"),
+		mergeInput("Tests", "A", "PASS"),
+	)
+	test := mergedTest(t, merged, "Tests.Login.A")
+	if test.FirstAttempt == nil || test.FirstAttempt.Message != "A FAIL" {
+		t.Fatalf("first attempt %+v, want the first FAIL", test.FirstAttempt)
+	}
+	first := FirstAttempts(merged)
+	if got := mergedTest(t, first, "Tests.Login.A").Status.Status; got != "FAIL" {
+		t.Errorf("FirstAttempts status %s, want FAIL", got)
+	}
+	if got := test.Status.Status; got != "PASS" {
+		t.Errorf("merged input changed by FirstAttempts: status %s", got)
+	}
+}
+
+func TestMergeRobotsRecomputesStatistics(t *testing.T) {
+	inputs := []*Robot{
+		mergeInput("Tests", "A", "FAIL", "B", "PASS"),
+		mergeInput("Tests", "A", "PASS"),
+	}
+	merged := MergeRobots(inputs...)
+	if merged.Statistics != nil {
+		t.Errorf("merged result kept the statistics of an input")
+	}
+	if got := merged.Suite.Status.Status; got != "PASS" {
+		t.Errorf("root status %s, want PASS", got)
+	}
+	if got := merged.Suite.Suites[0].Status.Status; got != "PASS" {
+		t.Errorf("suite status %s, want PASS", got)
+	}
+	tags := merged.TagStatistics()
+	if len(tags) != 1 || tags[0].Name != "login" || tags[0].Pass != 2 || tags[0].Fail != 0 {
+		t.Errorf("tag statistics %+v, want login with 2 passed", tags)
+	}
+	if stats := inputs[0].Statistics.Total.Stats[0]; stats.Fail != 1 {
+		t.Errorf("input statistics changed: %+v", stats)
+	}
+	if got := inputs[0].Suite.Suites[0].Tests[0].Status.Status; got != "FAIL" {
+		t.Errorf("input test changed to %s", got)
+	}
+}
+
+func TestMergeRobotsNotes(t *testing.T) {
+	merged := MergeRobotsWithOptions(MergeOptions{Notes: true},
+		mergeInput("Tests", "A", "FAIL", "B", "FAIL"),
+		mergeInput("Tests", "A", "PASS", "B", "SKIP"),
+	)
+	a := mergedTest(t, merged, "Tests.Login.A").Status.Message
+	if !strings.Contains(a, "Re-executed test has been merged.") || !strings.Contains(a, "A FAIL") || !strings.Contains(a, "A PASS") {
+		t.Errorf("merge note %q", a)
+	}
+	b := mergedTest(t, merged, "Tests.Login.B").Status.Message
+	if !strings.Contains(b, "Latter result had <span class=\"skip\">SKIP</span> status and was ignored.") || !strings.Contains(b, "B SKIP") {
+		t.Errorf("skipped rerun note %q", b)
+	}
+}
+
+func TestParseRobotTime(t *testing.T) {
+	local := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04:05.000", s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"2024-05-02T10:00:00.123456", local("2024-05-02 10:00:00.123").Add(456 * time.Microsecond), true},
+		{" 2024-05-02T10:00:00 ", local("2024-05-02 10:00:00.000"), true},
+		{"20240502 10:00:00.500", local("2024-05-02 10:00:00.500"), true},
+		{"2024-05-02T10:00:00Z", time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC), true},
+		{"N/A", time.Time{}, false},
+		{"", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRobotTime(tt.in)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ParseRobotTime(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
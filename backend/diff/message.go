@@ -0,0 +1,26 @@
+package robodiff
+
+import "regexp"
+
+var failureMessageNoise = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// Timestamps: 2024-01-01T10:00:00.123, 2024-01-01 10:00:00, 20240101 10:00:00.123, 10:00:00
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`), "<ts>"},
+	{regexp.MustCompile(`\d{8} \d{2}:\d{2}:\d{2}(?:\.\d+)?`), "<ts>"},
+	{regexp.MustCompile(`\b\d{1,2}:\d{2}:\d{2}(?:\.\d+)?\b`), "<ts>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<addr>"},
+	{regexp.MustCompile(`\d+(?:\.\d+)?`), "<n>"},
+}
+
+// NormalizeFailureMessage strips run-specific noise (timestamps, UUIDs, memory
+// addresses and numbers) from a status message so that failures with the same
+// cause compare equal across runs.
+func NormalizeFailureMessage(msg string) string {
+	for _, n := range failureMessageNoise {
+		msg = n.re.ReplaceAllString(msg, n.repl)
+	}
+	return normalizeSpace(msg)
+}
@@ -1,10 +1,11 @@
 package robodiff
 
 import (
+	"bytes"
 	"context"
-	"encoding/xml"
+	"errors"
+	"io"
 	"os"
-	"strings"
 )
 
 func ParseRobotXMLBytes(data []byte) (*Robot, error) {
@@ -16,60 +17,95 @@ func ParseRobotXMLFile(path string) (*Robot, error) {
 }
 
 func ParseRobotXMLBytesContext(ctx context.Context, data []byte) (*Robot, error) {
+	return ParseRobotXMLReaderContext(ctx, bytes.NewReader(data), ParseOptions{})
+}
+
+func ParseRobotXMLFileContext(ctx context.Context, path string) (*Robot, error) {
+	return ParseRobotXMLFileWithOptions(ctx, path, ParseOptions{})
+}
+
+// ParseRobotXMLFileWithOptions streams the file from disk instead of reading
+// it into memory first.
+func ParseRobotXMLFileWithOptions(ctx context.Context, path string, opts ParseOptions) (*Robot, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-
-	resultCh := make(chan *Robot, 1)
-	errCh := make(chan error, 1)
-
-	go func() {
-		var robot Robot
-		if err := xml.Unmarshal(data, &robot); err != nil {
-			errCh <- err
-			return
-		}
-		resultCh <- &robot
-	}()
-
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case err := <-errCh:
+	f, err := os.Open(path)
+	if err != nil {
 		return nil, err
-	case robot := <-resultCh:
-		return robot, nil
 	}
+	defer f.Close()
+	return ParseRobotXMLReaderContext(ctx, f, opts)
 }
 
-func ParseRobotXMLFileContext(ctx context.Context, path string) (*Robot, error) {
+// FindTestInFileContext decodes a single test from path. For XML and JSON
+// results the rest of the tree is never built. It returns (nil, nil) when
+// the test does not exist.
+func FindTestInFileContext(ctx context.Context, path, name string) (*Test, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	data, err := os.ReadFile(path)
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	return ParseRobotXMLBytesContext(ctx, data)
+	defer f.Close()
+	return findTestInReader(ctx, f, detectOpenFile(f), name)
 }
 
-func CountTests(suite *Suite) (pass int, fail int, total int) {
+func findTestInReader(ctx context.Context, r io.Reader, format, name string) (*Test, error) {
+	switch format {
+	case FormatRobotJSON:
+		return FindJSONTestInReaderContext(ctx, r, name)
+	case FormatJUnit:
+		robot, err := ParseJUnitReaderContext(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		return findTestInRobot(robot, name), nil
+	}
+	return FindTestInReaderContext(ctx, r, name)
+}
+
+// CountTests counts test results in suite and its children. SKIP and NOT RUN
+// both count as skipped.
+func CountTests(suite *Suite) (pass int, fail int, skip int, total int) {
 	for i := range suite.Suites {
-		p, f, t := CountTests(&suite.Suites[i])
+		p, f, sk, t := CountTests(&suite.Suites[i])
 		pass += p
 		fail += f
+		skip += sk
 		total += t
 	}
 
 	for _, test := range suite.Tests {
 		total++
-		s := strings.ToUpper(test.Status.Status)
-		if s == "PASS" {
+		switch NormalizeStatus(test.Status.Status) {
+		case "PASS":
 			pass++
-		} else if s == "FAIL" {
+		case "FAIL":
 			fail++
+		case "SKIP", "NOT RUN":
+			skip++
 		}
 	}
 
-	return pass, fail, total
+	return pass, fail, skip, total
+}
+
+// ReadTestAtFileContext decodes one test from path using the byte range the
+// streaming parser recorded in Test.Offset and Test.Length.
+func ReadTestAtFileContext(ctx context.Context, path string, offset, length int64) (*Test, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch detectOpenFile(f) {
+	case FormatRobotJSON:
+		return ReadJSONTestAtContext(ctx, f, offset, length)
+	case FormatJUnit:
+		return nil, errors.New("junit results have no recorded test offsets")
+	}
+	return ReadTestAtContext(ctx, f, offset, length)
 }
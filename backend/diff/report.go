@@ -3,25 +3,106 @@ package robodiff
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 // JSON output structures
 type JSONTest struct {
-	Name    string   `json:"name"`
-	Results []string `json:"results"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Explanation string   `json:"explanation"`
+	Results     []string `json:"results"`
+	Messages    []string `json:"messages"`
+	// RenamedFrom is the earlier long name of a test that was matched across
+	// a rename or move, MatchedBy how it was matched and ColumnNames its long
+	// name in each column ("" where it is missing).
+	RenamedFrom string   `json:"renamedFrom,omitempty"`
+	MatchedBy   string   `json:"matchedBy,omitempty"`
+	ColumnNames []string `json:"columnNames,omitempty"`
 }
 
+// JSONSuite is a suite with tests somewhere below it. Name is the suite's
+// own name and Path the names from the root suite down to it; Tests are the
+// suite's own tests and Suites its child suites.
 type JSONSuite struct {
-	Name  string     `json:"name"`
-	Tests []JSONTest `json:"tests"`
+	Name   string      `json:"name"`
+	Path   []string    `json:"path"`
+	Tests  []JSONTest  `json:"tests"`
+	Suites []JSONSuite `json:"suites,omitempty"`
+}
+
+// LongName returns the suite's dotted long name.
+func (s *JSONSuite) LongName() string {
+	return LongName(s.Path)
+}
+
+// AllSuites returns the suites of the report, each suite before its child
+// suites.
+func (r *JSONReport) AllSuites() []*JSONSuite {
+	var out []*JSONSuite
+	var walk func(suites []JSONSuite)
+	walk = func(suites []JSONSuite) {
+		for i := range suites {
+			out = append(out, &suites[i])
+			walk(suites[i].Suites)
+		}
+	}
+	walk(r.Suites)
+	return out
+}
+
+// JSONError is one execution error or warning.
+type JSONError struct {
+	Level     string `json:"level"`
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// JSONColumnErrors lists the execution errors of one column and those that
+// the previous column does not have.
+type JSONColumnErrors struct {
+	Column   string      `json:"column"`
+	Errors   int         `json:"errors"`
+	Warnings int         `json:"warnings"`
+	All      []JSONError `json:"all"`
+	New      []JSONError `json:"new"`
+}
+
+// JSONStatCount is the pass/fail/skip count of a tag in one column.
+type JSONStatCount struct {
+	Pass int `json:"pass"`
+	Fail int `json:"fail"`
+	Skip int `json:"skip"`
+}
+
+// JSONTagStat is one row of the tag summary. Counts has an entry per column,
+// nil where no test has the tag.
+type JSONTagStat struct {
+	Tag    string           `json:"tag"`
+	Counts []*JSONStatCount `json:"counts"`
+}
+
+// Changed reports whether the tag's counts differ between columns.
+func (t JSONTagStat) Changed() bool {
+	if len(t.Counts) < 2 {
+		return false
+	}
+	for _, c := range t.Counts[1:] {
+		if (c == nil) != (t.Counts[0] == nil) || (c != nil && *c != *t.Counts[0]) {
+			return true
+		}
+	}
+	return false
 }
 
 type JSONReport struct {
-	Title       string      `json:"title"`
-	Columns     []string    `json:"columns"`
-	ReportLinks []string    `json:"reportLinks"`
-	Suites      []JSONSuite `json:"suites"`
+	Title       string             `json:"title"`
+	Columns     []string           `json:"columns"`
+	ReportLinks []string           `json:"reportLinks"`
+	Suites      []JSONSuite        `json:"suites"`
+	Errors      []JSONColumnErrors `json:"errors"`
+	Tags        []JSONTagStat      `json:"tags"`
 }
 
 // DiffReporter builds the JSON diff payload used by the server/React UI.
@@ -61,64 +142,128 @@ func (dr *DiffReporter) detectReportLinks() []string {
 }
 
 func (dr *DiffReporter) BuildJSONData(results *DiffResults) *JSONReport {
-	suiteMap := make(map[string]*JSONSuite)
-	var suiteOrder []string
-
-	rows := results.Rows()
 	reportLinks := dr.detectReportLinks()
 
-	suiteNames := make(map[string]bool)
-	for _, row := range rows {
-		for _, otherRow := range rows {
-			if otherRow.Name != row.Name && strings.HasPrefix(otherRow.Name, row.Name+".") {
-				suiteNames[row.Name] = true
-				break
-			}
+	var suites []JSONSuite
+	for _, node := range results.tree().children {
+		if suite, ok := jsonSuite(results, node, []string{node.name}); ok {
+			suites = append(suites, suite)
 		}
 	}
+	if suites == nil {
+		suites = make([]JSONSuite, 0)
+	}
 
-	for _, row := range rows {
-		if suiteNames[row.Name] {
-			continue
+	columnErrors := make([]JSONColumnErrors, len(dr.columns))
+	for i, column := range dr.columns {
+		all := results.ExecutionErrors(i)
+		errs, warnings := CountExecutionErrors(all)
+		columnErrors[i] = JSONColumnErrors{
+			Column:   column,
+			Errors:   errs,
+			Warnings: warnings,
+			All:      jsonErrors(all),
+			New:      jsonErrors(results.NewExecutionErrors(i)),
 		}
+	}
 
-		lastDot := strings.LastIndex(row.Name, ".")
-		if lastDot < 0 {
+	return &JSONReport{
+		Title:       dr.title,
+		Columns:     dr.columns,
+		ReportLinks: reportLinks,
+		Suites:      suites,
+		Errors:      columnErrors,
+		Tags:        buildTagSummary(results, len(dr.columns)),
+	}
+}
+
+// jsonSuite builds the report suite of node. ok is false when there are no
+// tests below the suite.
+func jsonSuite(results *DiffResults, node *rowTree, path []string) (suite JSONSuite, ok bool) {
+	suite = JSONSuite{Name: node.name, Path: path, Tests: make([]JSONTest, 0)}
+	for _, c := range node.children {
+		if c.test {
+			suite.Tests = append(suite.Tests, reportTest(results.newRow(c.key)))
+		}
+		if len(c.children) == 0 {
 			continue
 		}
+		if child, ok := jsonSuite(results, c, append(path[:len(path):len(path)], c.name)); ok {
+			suite.Suites = append(suite.Suites, child)
+		}
+	}
+	return suite, len(suite.Tests) > 0 || len(suite.Suites) > 0
+}
 
-		suiteName := row.Name[:lastDot]
-		testName := row.Name[lastDot+1:]
-
-		if _, exists := suiteMap[suiteName]; !exists {
-			suiteMap[suiteName] = &JSONSuite{Name: suiteName, Tests: make([]JSONTest, 0)}
-			suiteOrder = append(suiteOrder, suiteName)
+// reportTest converts a test row.
+func reportTest(row *RowStatus) JSONTest {
+	testResults := make([]string, len(row.Statuses()))
+	testMessages := make([]string, len(row.Statuses()))
+	for i, status := range row.Statuses() {
+		if status.Name == "N/A" {
+			testResults[i] = "MISSING"
+		} else {
+			testResults[i] = status.Name
 		}
+		testMessages[i] = status.Message
+	}
 
-		testResults := make([]string, len(row.Statuses()))
+	test := JSONTest{
+		Name:        row.Path[len(row.Path)-1],
+		Status:      row.Status(),
+		Explanation: row.Explanation(),
+		Results:     testResults,
+		Messages:    testMessages,
+	}
+	if row.RenamedFrom != "" {
+		test.RenamedFrom = row.RenamedFrom
+		test.MatchedBy = row.MatchedBy
+		test.Explanation += ", renamed from " + row.RenamedFrom
+		test.ColumnNames = make([]string, len(row.Statuses()))
 		for i, status := range row.Statuses() {
-			if status.Name == "N/A" {
-				testResults[i] = "MISSING"
-			} else {
-				testResults[i] = status.Name
+			if status.Name != "N/A" {
+				test.ColumnNames[i] = status.LongName
 			}
 		}
-
-		suiteMap[suiteName].Tests = append(suiteMap[suiteName].Tests, JSONTest{Name: testName, Results: testResults})
 	}
+	return test
+}
 
-	suites := make([]JSONSuite, 0, len(suiteOrder))
-	for _, name := range suiteOrder {
-		suite := suiteMap[name]
-		if len(suite.Tests) > 0 {
-			suites = append(suites, *suite)
+// buildTagSummary lines up the tag statistics of the columns. Tags are
+// matched like Robot matches them (case, space and underscore insensitive)
+// and sorted by name.
+func buildTagSummary(results *DiffResults, columns int) []JSONTagStat {
+	rows := make(map[string]*JSONTagStat)
+	var keys []string
+	for i := 0; i < columns; i++ {
+		for _, st := range results.TagStatistics(i) {
+			key := normalizeTag(st.Name)
+			row := rows[key]
+			if row == nil {
+				row = &JSONTagStat{Tag: st.Name, Counts: make([]*JSONStatCount, columns)}
+				rows[key] = row
+				keys = append(keys, key)
+			}
+			if row.Counts[i] == nil {
+				row.Counts[i] = &JSONStatCount{}
+			}
+			row.Counts[i].Pass += st.Pass
+			row.Counts[i].Fail += st.Fail
+			row.Counts[i].Skip += st.Skip
 		}
 	}
+	sort.Strings(keys)
+	out := make([]JSONTagStat, len(keys))
+	for i, key := range keys {
+		out[i] = *rows[key]
+	}
+	return out
+}
 
-	return &JSONReport{
-		Title:       dr.title,
-		Columns:     dr.columns,
-		ReportLinks: reportLinks,
-		Suites:      suites,
+func jsonErrors(msgs []Message) []JSONError {
+	out := make([]JSONError, len(msgs))
+	for i, msg := range msgs {
+		out[i] = JSONError{Level: msg.Level, Timestamp: msg.Timestamp, Message: strings.TrimSpace(msg.Text)}
 	}
+	return out
 }
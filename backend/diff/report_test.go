@@ -0,0 +1,97 @@
+package robodiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildJSONDataNestsDottedNames(t *testing.T) {
+	robot := func(status string) *Robot {
+		api := identitySuite("Verify v1.2 API", "/t/api/v1.2.robot", "GET /users")
+		api.Tests[0].Status.Status = status
+		return identityRobot(
+			Suite{
+				Name:   "example.com login",
+				Source: "/t/api",
+				Status: Status{Status: "PASS"},
+				Suites: []Suite{api},
+			},
+			identitySuite("Smoke", "/t/smoke.robot", "Site v2.0 loads"),
+		)
+	}
+	results := NewDiffResults()
+	results.AddParsedOutput(robot("PASS"), "before")
+	results.AddParsedOutput(robot("FAIL"), "after")
+	report := NewDiffReporter("Robodiff", []string{"before", "after"}, nil).BuildJSONData(results)
+
+	type suite struct {
+		path  []string
+		tests []string
+	}
+	var got []suite
+	for _, s := range report.AllSuites() {
+		var tests []string
+		for _, test := range s.Tests {
+			tests = append(tests, test.Name+" "+test.Status)
+		}
+		got = append(got, suite{s.Path, tests})
+	}
+	want := []suite{
+		{[]string{"Tests"}, nil},
+		{[]string{"Tests", "example.com login"}, nil},
+		{[]string{"Tests", "example.com login", "Verify v1.2 API"}, []string{"GET /users diff"}},
+		{[]string{"Tests", "Smoke"}, []string{"Site v2.0 loads all_passed"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("suites:\n got %v\nwant %v", got, want)
+	}
+	if len(report.Suites) != 1 || len(report.Suites[0].Suites) != 2 {
+		t.Fatalf("want one root suite with two children, got %+v", report.Suites)
+	}
+	if name := report.Suites[0].Suites[0].Name; name != "example.com login" {
+		t.Errorf("first child suite %q, want %q", name, "example.com login")
+	}
+	api := report.Suites[0].Suites[0].Suites[0]
+	if api.Name != "Verify v1.2 API" || api.LongName() != "Tests.example.com login.Verify v1.2 API" {
+		t.Errorf("nested suite %q with long name %q", api.Name, api.LongName())
+	}
+}
+
+func TestBuildJSONDataSkipsSuitesWithoutTests(t *testing.T) {
+	before := identityRobot(
+		identitySuite("Login", "/t/login.robot", "Valid Login"),
+		identitySuite("Setup Only", "/t/setup.robot"),
+		identitySuite("Emptied", "/t/emptied.robot", "Removed Test"),
+	)
+	after := identityRobot(
+		identitySuite("Login", "/t/login.robot", "Valid Login"),
+		identitySuite("Setup Only", "/t/setup.robot"),
+		identitySuite("Emptied", "/t/emptied.robot"),
+	)
+	results := NewDiffResults()
+	results.AddParsedOutput(before, "before")
+	results.AddParsedOutput(after, "after")
+
+	for _, row := range results.Rows() {
+		if row.Name == "Tests.Setup Only" {
+			t.Errorf("suite without tests listed as a row")
+		}
+	}
+	report := NewDiffReporter("Robodiff", []string{"before", "after"}, nil).BuildJSONData(results)
+	got := make(map[string][]string)
+	for _, s := range report.AllSuites() {
+		for _, test := range s.Tests {
+			got[s.LongName()] = append(got[s.LongName()], test.Name)
+		}
+		if s.LongName() == "Tests.Setup Only" {
+			t.Errorf("suite without tests in the report")
+		}
+	}
+	want := map[string][]string{
+		"Tests.Login":   {"Valid Login"},
+		"Tests.Emptied": {"Removed Test"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tests by suite:\n got %v\nwant %v", got, want)
+	}
+}
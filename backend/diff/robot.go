@@ -4,32 +4,48 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
 // Robot Framework XML structures
 type Robot struct {
-	XMLName xml.Name `xml:"robot"`
-	Suite   Suite    `xml:"suite"`
+	XMLName    xml.Name    `xml:"robot"`
+	Suite      Suite       `xml:"suite"`
 	Statistics *Statistics `xml:"statistics"`
+	// Errors are the execution errors and warnings Robot logged outside of
+	// tests (the <errors> section): failed imports, invalid syntax and the
+	// like, which can make tests silently disappear.
+	Errors []Message `xml:"-"`
 }
 
-// Statistics mirrors the <statistics> section near the end of output.xml.
-// We currently only rely on the <total> numbers ("All Tests") for accurate
-// pass/fail/skip counts.
+// Statistics mirrors the <statistics> section near the end of output.xml:
+// the <total> numbers ("All Tests") give accurate pass/fail/skip counts, and
+// <tag> and <suite> hold one stat per tag and per suite.
 type Statistics struct {
-	Total TotalStats `xml:"total"`
+	Total  TotalStats `xml:"total"`
+	Tags   []Stat     `xml:"tag>stat"`
+	Suites []Stat     `xml:"suite>stat"`
 }
 
 type TotalStats struct {
 	Stats []Stat `xml:"stat"`
 }
 
+// Stat is one row of the statistics. Name is the tag, or the suite's full
+// name; suite stats also carry the suite id and short name, and tag stats the
+// --tagstatcombine pattern and --tagdoc documentation when set.
 type Stat struct {
 	Pass int    `xml:"pass,attr"`
 	Fail int    `xml:"fail,attr"`
 	Skip int    `xml:"skip,attr"`
 	Name string `xml:",chardata"`
+
+	ID        string `xml:"id,attr"`
+	SuiteName string `xml:"name,attr"`
+	Info      string `xml:"info,attr"`
+	Combined  string `xml:"combined,attr"`
+	Doc       string `xml:"doc,attr"`
 }
 
 func (t TotalStats) AllTests() (pass, fail, skip int, ok bool) {
@@ -49,40 +65,93 @@ func (t TotalStats) AllTests() (pass, fail, skip int, ok bool) {
 }
 
 type Suite struct {
-	Name   string  `xml:"name,attr"`
-	Suites []Suite `xml:"suite"`
-	Tests  []Test  `xml:"test"`
-	Status Status  `xml:"status"`
+	Name     string     `xml:"name,attr"`
+	Source   string     `xml:"source,attr"`
+	Doc      string     `xml:"doc"`
+	Metadata []Metadata `xml:"meta"`
+	// Setup and Teardown are the suite's own fixture keywords, nil when the
+	// suite has none. They are always kept in full, whatever the
+	// ParseOptions, because a failing suite setup fails every test below it.
+	Setup    *Keyword `xml:"-"`
+	Teardown *Keyword `xml:"-"`
+	Suites   []Suite  `xml:"suite"`
+	Tests    []Test   `xml:"test"`
+	Status   Status   `xml:"status"`
+}
+
+// Metadata is one suite metadata entry (<meta name="...">value</meta>), kept
+// in file order.
+type Metadata struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
 }
 
 type Test struct {
-	Name     string    `xml:"name,attr"`
-	Status   Status    `xml:"status"`
-	Keywords []Keyword `xml:"kw"`
-	Ifs      []If      `xml:"if"`
-	Fors     []For     `xml:"for"`
+	Name     string     `xml:"name,attr"`
+	Tags     []string   `xml:"tag"`
+	Doc      string     `xml:"doc"`
+	Timeout  string     `xml:"timeout"`
+	Status   Status     `xml:"status"`
+	Keywords []Keyword  `xml:"kw"`
+	Ifs      []If       `xml:"if"`
+	Fors     []For      `xml:"for"`
 	Body     []BodyItem `xml:"-"`
+	// BodyTruncated is set when ParseOptions dropped part of the body.
+	BodyTruncated bool `xml:"-"`
+	// Offset and Length locate the <test> element in the source file when it
+	// was read by the streaming parser (see ReadTestAtContext).
+	Offset int64 `xml:"-"`
+	Length int64 `xml:"-"`
+	// FirstAttempt is the status of the first run of a test that was
+	// re-executed and merged (see MergeRobots); nil otherwise.
+	FirstAttempt *Status `xml:"-"`
+	// Input is the index of the merged result the test was read from, which
+	// is where Offset and Length point.
+	Input int `xml:"-"`
 }
 
 type Keyword struct {
-	Name      string    `xml:"name,attr"`
-	Type      string    `xml:"type,attr"`
-	Keywords  []Keyword `xml:"kw"`
-	Ifs       []If      `xml:"if"`
-	Fors      []For     `xml:"for"`
-	Arguments []string  `xml:"arg"`
-	Messages  []Message `xml:"msg"`
-	Status    Status    `xml:"status"`
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+	// Owner is the library or resource file the keyword comes from (RF 7
+	// "owner", older "library"), SourceName the name it is defined with
+	// when that differs, e.g. for embedded arguments.
+	Owner      string `xml:"owner,attr"`
+	SourceName string `xml:"source_name,attr"`
+	// Source and Line locate the keyword when the output records them.
+	Source string `xml:"source,attr"`
+	Line   int    `xml:"line,attr"`
+	// Assign holds the variables the return value is assigned to, such as
+	// "${resp}".
+	Assign    []string   `xml:"var"`
+	Tags      []string   `xml:"tag"`
+	Doc       string     `xml:"doc"`
+	Timeout   string     `xml:"-"`
+	Keywords  []Keyword  `xml:"kw"`
+	Ifs       []If       `xml:"if"`
+	Fors      []For      `xml:"for"`
+	Arguments []string   `xml:"arg"`
+	Messages  []Message  `xml:"msg"`
+	Status    Status     `xml:"status"`
 	Body      []BodyItem `xml:"-"`
 }
 
 // BodyItem represents an ordered child element of a container where Robot may
-// interleave <kw>, <if> and <for>. We convert IF/FOR into pseudo-keywords
-// later for the UI, but we must preserve order.
+// interleave <kw>, <if>, <for> and the other control structures. We convert
+// control structures into pseudo-keywords later for the UI, but we must
+// preserve order. The structures added after IF/FOR are only kept here, not in
+// the per-type slices.
 type BodyItem struct {
-	Keyword *Keyword
-	If      *If
-	For     *For
+	Keyword  *Keyword
+	If       *If
+	For      *For
+	While    *While
+	Try      *Try
+	Break    *Break
+	Continue *Continue
+	Var      *Var
+	Group    *Group
+	Return   *Return
 }
 
 // Control structures (Robot Framework IF/FOR)
@@ -93,30 +162,34 @@ type If struct {
 }
 
 type Branch struct {
-	Type      string   `xml:"type,attr"`
-	Condition string   `xml:"condition,attr"`
-	Keywords  []Keyword `xml:"kw"`
-	Ifs       []If      `xml:"if"`
-	Fors      []For     `xml:"for"`
-	Return    *Return   `xml:"return"`
-	Status    Status    `xml:"status"`
-	Body      []BodyItem `xml:"-"`
+	Type      string `xml:"type,attr"`
+	Condition string `xml:"condition,attr"`
+	// Patterns, PatternType and Assign are only set on EXCEPT branches.
+	Patterns    []string   `xml:"pattern"`
+	PatternType string     `xml:"pattern_type,attr"`
+	Assign      string     `xml:"assign,attr"`
+	Keywords    []Keyword  `xml:"kw"`
+	Ifs         []If       `xml:"if"`
+	Fors        []For      `xml:"for"`
+	Return      *Return    `xml:"return"`
+	Status      Status     `xml:"status"`
+	Body        []BodyItem `xml:"-"`
 }
 
 type For struct {
-	Flavor string `xml:"flavor,attr"`
-	Iter   []Iter `xml:"iter"`
+	Flavor string   `xml:"flavor,attr"`
+	Iter   []Iter   `xml:"iter"`
 	Var    []string `xml:"var"`
 	Value  []string `xml:"value"`
-	Status Status `xml:"status"`
+	Status Status   `xml:"status"`
 }
 
 type Iter struct {
-	Keywords []Keyword `xml:"kw"`
-	Ifs      []If      `xml:"if"`
-	Fors     []For     `xml:"for"`
-	Return   *Return   `xml:"return"`
-	Status   Status    `xml:"status"`
+	Keywords []Keyword  `xml:"kw"`
+	Ifs      []If       `xml:"if"`
+	Fors     []For      `xml:"for"`
+	Return   *Return    `xml:"return"`
+	Status   Status     `xml:"status"`
 	Body     []BodyItem `xml:"-"`
 }
 
@@ -125,6 +198,46 @@ type Return struct {
 	Status Status   `xml:"status"`
 }
 
+// Control structures added in Robot Framework 5-7. Iterations of WHILE and
+// branches of TRY reuse Iter and Branch.
+type While struct {
+	Condition      string `xml:"condition,attr"`
+	Limit          string `xml:"limit,attr"`
+	OnLimit        string `xml:"on_limit,attr"`
+	OnLimitMessage string `xml:"on_limit_message,attr"`
+	Iter           []Iter `xml:"iter"`
+	Status         Status `xml:"status"`
+}
+
+type Try struct {
+	Branches []Branch `xml:"branch"`
+	Status   Status   `xml:"status"`
+}
+
+type Break struct {
+	Status Status `xml:"status"`
+}
+
+type Continue struct {
+	Status Status `xml:"status"`
+}
+
+// Var is the VAR syntax (RF 7), written as <variable> in output.xml.
+type Var struct {
+	Name      string   `xml:"name,attr"`
+	Scope     string   `xml:"scope,attr"`
+	Separator string   `xml:"separator,attr"`
+	Value     []string `xml:"var"`
+	Status    Status   `xml:"status"`
+}
+
+// Group is a named block of body items (RF 7.2).
+type Group struct {
+	Name   string     `xml:"name,attr"`
+	Status Status     `xml:"status"`
+	Body   []BodyItem `xml:"-"`
+}
+
 type Message struct {
 	Level     string `xml:"level,attr"`
 	Timestamp string `xml:"timestamp,attr"`
@@ -144,11 +257,7 @@ type Status struct {
 
 func (t *Test) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	*t = Test{}
-	for _, a := range start.Attr {
-		if a.Name.Local == "name" {
-			t.Name = a.Value
-		}
-	}
+	t.decodeAttrs(start)
 
 	for {
 		tok, err := d.Token()
@@ -160,13 +269,12 @@ func (t *Test) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		}
 		switch se := tok.(type) {
 		case xml.StartElement:
+			if handled, err := t.decodeHeaderElement(d, se); err != nil {
+				return err
+			} else if handled {
+				continue
+			}
 			switch se.Name.Local {
-			case "status":
-				var st Status
-				if err := d.DecodeElement(&st, &se); err != nil {
-					return err
-				}
-				t.Status = st
 			case "kw":
 				var kw Keyword
 				if err := d.DecodeElement(&kw, &se); err != nil {
@@ -189,7 +297,11 @@ func (t *Test) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				t.Fors = append(t.Fors, forblk)
 				t.Body = append(t.Body, BodyItem{For: &forblk})
 			default:
-				if err := d.Skip(); err != nil {
+				if item, ok, err := decodeControlElement(d, se); err != nil {
+					return err
+				} else if ok {
+					t.Body = append(t.Body, item)
+				} else if err := d.Skip(); err != nil {
 					return err
 				}
 			}
@@ -201,6 +313,63 @@ func (t *Test) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	}
 }
 
+func (t *Test) decodeAttrs(start xml.StartElement) {
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "name":
+			t.Name = a.Value
+		case "timeout":
+			// Robot < 4 writes the timeout as an attribute on <test>.
+			t.Timeout = a.Value
+		}
+	}
+}
+
+// decodeHeaderElement decodes the non-body children of <test> (status, tags,
+// doc, timeout). It reports false for anything else.
+func (t *Test) decodeHeaderElement(d *xml.Decoder, se xml.StartElement) (bool, error) {
+	switch se.Name.Local {
+	case "status":
+		var st Status
+		if err := d.DecodeElement(&st, &se); err != nil {
+			return true, err
+		}
+		t.Status = st
+	case "tag":
+		var tag string
+		if err := d.DecodeElement(&tag, &se); err != nil {
+			return true, err
+		}
+		t.Tags = append(t.Tags, tag)
+	case "tags":
+		// Robot < 4 wraps tags in <tags><tag>..</tag></tags>.
+		var tags struct {
+			Tags []string `xml:"tag"`
+		}
+		if err := d.DecodeElement(&tags, &se); err != nil {
+			return true, err
+		}
+		t.Tags = append(t.Tags, tags.Tags...)
+	case "doc":
+		var doc string
+		if err := d.DecodeElement(&doc, &se); err != nil {
+			return true, err
+		}
+		t.Doc = doc
+	case "timeout":
+		var timeout struct {
+			Value string `xml:"value,attr"`
+		}
+		if err := d.DecodeElement(&timeout, &se); err != nil {
+			return true, err
+		}
+		t.Timeout = timeout.Value
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
 func (k *Keyword) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	*k = Keyword{}
 	for _, a := range start.Attr {
@@ -209,6 +378,14 @@ func (k *Keyword) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			k.Name = a.Value
 		case "type":
 			k.Type = a.Value
+		case "owner", "library":
+			k.Owner = a.Value
+		case "source_name", "sourcename":
+			k.SourceName = a.Value
+		case "source":
+			k.Source = a.Value
+		case "line", "lineno":
+			k.Line, _ = strconv.Atoi(strings.TrimSpace(a.Value))
 		}
 	}
 
@@ -250,6 +427,47 @@ func (k *Keyword) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				k.Arguments = append(k.Arguments, arg)
+			case "var":
+				var v string
+				if err := d.DecodeElement(&v, &se); err != nil {
+					return err
+				}
+				k.Assign = append(k.Assign, v)
+			case "assign":
+				// Robot < 4 wraps assignments in <assign><var>..</var></assign>.
+				var assign struct {
+					Vars []string `xml:"var"`
+				}
+				if err := d.DecodeElement(&assign, &se); err != nil {
+					return err
+				}
+				k.Assign = append(k.Assign, assign.Vars...)
+			case "tag":
+				var tag string
+				if err := d.DecodeElement(&tag, &se); err != nil {
+					return err
+				}
+				k.Tags = append(k.Tags, tag)
+			case "tags":
+				var tags struct {
+					Tags []string `xml:"tag"`
+				}
+				if err := d.DecodeElement(&tags, &se); err != nil {
+					return err
+				}
+				k.Tags = append(k.Tags, tags.Tags...)
+			case "doc":
+				if err := d.DecodeElement(&k.Doc, &se); err != nil {
+					return err
+				}
+			case "timeout":
+				var timeout struct {
+					Value string `xml:"value,attr"`
+				}
+				if err := d.DecodeElement(&timeout, &se); err != nil {
+					return err
+				}
+				k.Timeout = timeout.Value
 			case "msg":
 				var msg Message
 				if err := d.DecodeElement(&msg, &se); err != nil {
@@ -263,7 +481,11 @@ func (k *Keyword) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				}
 				k.Status = st
 			default:
-				if err := d.Skip(); err != nil {
+				if item, ok, err := decodeControlElement(d, se); err != nil {
+					return err
+				} else if ok {
+					k.Body = append(k.Body, item)
+				} else if err := d.Skip(); err != nil {
 					return err
 				}
 			}
@@ -283,6 +505,11 @@ func (b *Branch) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			b.Type = a.Value
 		case "condition":
 			b.Condition = a.Value
+		case "pattern_type":
+			b.PatternType = a.Value
+		case "assign", "variable":
+			// RF 5/6 called the EXCEPT ... AS target "variable".
+			b.Assign = a.Value
 		}
 	}
 
@@ -324,6 +551,12 @@ func (b *Branch) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				b.Return = &ret
+			case "pattern":
+				var pattern string
+				if err := d.DecodeElement(&pattern, &se); err != nil {
+					return err
+				}
+				b.Patterns = append(b.Patterns, pattern)
 			case "status":
 				var st Status
 				if err := d.DecodeElement(&st, &se); err != nil {
@@ -331,7 +564,11 @@ func (b *Branch) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				}
 				b.Status = st
 			default:
-				if err := d.Skip(); err != nil {
+				if item, ok, err := decodeControlElement(d, se); err != nil {
+					return err
+				} else if ok {
+					b.Body = append(b.Body, item)
+				} else if err := d.Skip(); err != nil {
 					return err
 				}
 			}
@@ -390,7 +627,102 @@ func (it *Iter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				}
 				it.Status = st
 			default:
-				if err := d.Skip(); err != nil {
+				if item, ok, err := decodeControlElement(d, se); err != nil {
+					return err
+				} else if ok {
+					it.Body = append(it.Body, item)
+				} else if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if se.Name.Local == start.Name.Local {
+				return nil
+			}
+		}
+	}
+}
+
+// decodeControlElement decodes the control structures that only live in the
+// ordered body (WHILE, TRY, BREAK, CONTINUE, VAR, GROUP and a bare RETURN). It
+// reports false for any other element.
+func decodeControlElement(d *xml.Decoder, se xml.StartElement) (BodyItem, bool, error) {
+	var item BodyItem
+	var v any
+	switch se.Name.Local {
+	case "while":
+		item.While = &While{}
+		v = item.While
+	case "try":
+		item.Try = &Try{}
+		v = item.Try
+	case "break":
+		item.Break = &Break{}
+		v = item.Break
+	case "continue":
+		item.Continue = &Continue{}
+		v = item.Continue
+	case "variable":
+		item.Var = &Var{}
+		v = item.Var
+	case "group":
+		item.Group = &Group{}
+		v = item.Group
+	case "return":
+		item.Return = &Return{}
+		v = item.Return
+	default:
+		return BodyItem{}, false, nil
+	}
+	if err := d.DecodeElement(v, &se); err != nil {
+		return BodyItem{}, true, err
+	}
+	return item, true, nil
+}
+
+func (g *Group) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*g = Group{Name: attrValue(start, "name")}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		switch se := tok.(type) {
+		case xml.StartElement:
+			switch se.Name.Local {
+			case "kw":
+				var kw Keyword
+				if err := d.DecodeElement(&kw, &se); err != nil {
+					return err
+				}
+				g.Body = append(g.Body, BodyItem{Keyword: &kw})
+			case "if":
+				var ifblk If
+				if err := d.DecodeElement(&ifblk, &se); err != nil {
+					return err
+				}
+				g.Body = append(g.Body, BodyItem{If: &ifblk})
+			case "for":
+				var forblk For
+				if err := d.DecodeElement(&forblk, &se); err != nil {
+					return err
+				}
+				g.Body = append(g.Body, BodyItem{For: &forblk})
+			case "status":
+				var st Status
+				if err := d.DecodeElement(&st, &se); err != nil {
+					return err
+				}
+				g.Status = st
+			default:
+				if item, ok, err := decodeControlElement(d, se); err != nil {
+					return err
+				} else if ok {
+					g.Body = append(g.Body, item)
+				} else if err := d.Skip(); err != nil {
 					return err
 				}
 			}
@@ -412,6 +744,9 @@ func (m *Message) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			m.Level = a.Value
 		case "timestamp", "time":
 			m.Timestamp = a.Value
+		case "html":
+			// RF 7 writes "true", older versions "yes".
+			m.HTML = strings.EqualFold(a.Value, "true") || strings.EqualFold(a.Value, "yes")
 		}
 	}
 	var text string
@@ -437,17 +772,29 @@ func (s *Status) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		}
 	}
 
-	// Drain any nested content if present.
+	// The element text is the status message (e.g. the failure reason).
+	var text strings.Builder
 	for {
 		tok, err := d.Token()
 		if err != nil {
 			if err == io.EOF {
+				s.Message = text.String()
 				return nil
 			}
 			return err
 		}
-		if end, ok := tok.(xml.EndElement); ok && end.Name.Local == start.Name.Local {
-			return nil
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if t.Name.Local == start.Name.Local {
+				s.Message = text.String()
+				return nil
+			}
 		}
 	}
 }
@@ -461,6 +808,20 @@ func (b BodyItem) String() string {
 		return "if"
 	case b.For != nil:
 		return "for"
+	case b.While != nil:
+		return "while"
+	case b.Try != nil:
+		return "try"
+	case b.Break != nil:
+		return "break"
+	case b.Continue != nil:
+		return "continue"
+	case b.Var != nil:
+		return fmt.Sprintf("var:%s", b.Var.Name)
+	case b.Group != nil:
+		return fmt.Sprintf("group:%s", b.Group.Name)
+	case b.Return != nil:
+		return "return"
 	default:
 		return "<empty>"
 	}
@@ -0,0 +1,155 @@
+package robodiff
+
+import "unsafe"
+
+// ApproxMemory estimates the bytes held by the parsed tree: struct sizes plus
+// string and slice payloads. It ignores allocator overhead, so treat it as a
+// lower bound suitable for budgeting, not an exact figure.
+func (r *Robot) ApproxMemory() int64 {
+	if r == nil {
+		return 0
+	}
+	n := int64(unsafe.Sizeof(*r)) + messagesMemory(r.Errors)
+	if r.Statistics != nil {
+		n += int64(unsafe.Sizeof(*r.Statistics))
+		for _, stats := range [][]Stat{r.Statistics.Total.Stats, r.Statistics.Tags, r.Statistics.Suites} {
+			for _, st := range stats {
+				n += int64(unsafe.Sizeof(st)) + int64(len(st.Name)+len(st.ID)+len(st.SuiteName)+len(st.Info)+len(st.Combined)+len(st.Doc))
+			}
+		}
+	}
+	return n + suiteMemory(&r.Suite)
+}
+
+func suiteMemory(s *Suite) int64 {
+	n := int64(len(s.Name)+len(s.Source)+len(s.Doc)) + statusMemory(s.Status)
+	for _, m := range s.Metadata {
+		n += int64(unsafe.Sizeof(m)) + int64(len(m.Name)+len(m.Value))
+	}
+	for _, kw := range []*Keyword{s.Setup, s.Teardown} {
+		if kw != nil {
+			n += int64(unsafe.Sizeof(*kw)) + keywordMemory(kw)
+		}
+	}
+	for i := range s.Suites {
+		n += int64(unsafe.Sizeof(s.Suites[i])) + suiteMemory(&s.Suites[i])
+	}
+	for i := range s.Tests {
+		n += int64(unsafe.Sizeof(s.Tests[i])) + testMemory(&s.Tests[i])
+	}
+	return n
+}
+
+func testMemory(t *Test) int64 {
+	n := int64(len(t.Name)+len(t.Doc)+len(t.Timeout)) + stringsMemory(t.Tags) + statusMemory(t.Status)
+	return n + bodyMemory(t.Keywords, t.Ifs, t.Fors, t.Body)
+}
+
+func bodyMemory(kws []Keyword, ifs []If, fors []For, body []BodyItem) int64 {
+	n := int64(len(body)) * int64(unsafe.Sizeof(BodyItem{}))
+	for i := range kws {
+		n += int64(unsafe.Sizeof(kws[i])) + keywordMemory(&kws[i])
+	}
+	for i := range ifs {
+		n += int64(unsafe.Sizeof(ifs[i])) + ifMemory(&ifs[i])
+	}
+	for i := range fors {
+		n += int64(unsafe.Sizeof(fors[i])) + forMemory(&fors[i])
+	}
+	// Body items point at separate copies of the same elements.
+	for _, it := range body {
+		switch {
+		case it.Keyword != nil:
+			n += int64(unsafe.Sizeof(*it.Keyword))
+		case it.If != nil:
+			n += int64(unsafe.Sizeof(*it.If))
+		case it.For != nil:
+			n += int64(unsafe.Sizeof(*it.For))
+		case it.While != nil:
+			n += int64(unsafe.Sizeof(*it.While)) + whileMemory(it.While)
+		case it.Try != nil:
+			n += int64(unsafe.Sizeof(*it.Try)) + statusMemory(it.Try.Status) + branchesMemory(it.Try.Branches)
+		case it.Break != nil:
+			n += int64(unsafe.Sizeof(*it.Break)) + statusMemory(it.Break.Status)
+		case it.Continue != nil:
+			n += int64(unsafe.Sizeof(*it.Continue)) + statusMemory(it.Continue.Status)
+		case it.Var != nil:
+			v := it.Var
+			n += int64(unsafe.Sizeof(*v)) + int64(len(v.Name)+len(v.Scope)+len(v.Separator)) + stringsMemory(v.Value) + statusMemory(v.Status)
+		case it.Group != nil:
+			n += int64(unsafe.Sizeof(*it.Group)) + int64(len(it.Group.Name)) + statusMemory(it.Group.Status)
+			n += bodyMemory(nil, nil, nil, it.Group.Body)
+		case it.Return != nil:
+			n += returnMemory(it.Return)
+		}
+	}
+	return n
+}
+
+func keywordMemory(k *Keyword) int64 {
+	n := int64(len(k.Name)+len(k.Type)+len(k.Owner)+len(k.SourceName)+len(k.Source)+len(k.Doc)+len(k.Timeout)) +
+		stringsMemory(k.Arguments) + stringsMemory(k.Assign) + stringsMemory(k.Tags) + statusMemory(k.Status)
+	return n + messagesMemory(k.Messages) + bodyMemory(k.Keywords, k.Ifs, k.Fors, k.Body)
+}
+
+func messagesMemory(msgs []Message) int64 {
+	var n int64
+	for _, m := range msgs {
+		n += int64(unsafe.Sizeof(m)) + int64(len(m.Level)+len(m.Timestamp)+len(m.Text))
+	}
+	return n
+}
+
+func ifMemory(ifblk *If) int64 {
+	return statusMemory(ifblk.Status) + branchesMemory(ifblk.Branches)
+}
+
+func branchesMemory(branches []Branch) int64 {
+	var n int64
+	for i := range branches {
+		br := &branches[i]
+		n += int64(unsafe.Sizeof(*br)) + int64(len(br.Type)+len(br.Condition)+len(br.PatternType)+len(br.Assign))
+		n += stringsMemory(br.Patterns) + statusMemory(br.Status) + returnMemory(br.Return)
+		n += bodyMemory(br.Keywords, br.Ifs, br.Fors, br.Body)
+	}
+	return n
+}
+
+func forMemory(forblk *For) int64 {
+	n := int64(len(forblk.Flavor)) + stringsMemory(forblk.Var) + stringsMemory(forblk.Value) + statusMemory(forblk.Status)
+	return n + itersMemory(forblk.Iter)
+}
+
+func whileMemory(w *While) int64 {
+	n := int64(len(w.Condition)+len(w.Limit)+len(w.OnLimit)+len(w.OnLimitMessage)) + statusMemory(w.Status)
+	return n + itersMemory(w.Iter)
+}
+
+func itersMemory(iters []Iter) int64 {
+	var n int64
+	for i := range iters {
+		it := &iters[i]
+		n += int64(unsafe.Sizeof(*it)) + statusMemory(it.Status) + returnMemory(it.Return)
+		n += bodyMemory(it.Keywords, it.Ifs, it.Fors, it.Body)
+	}
+	return n
+}
+
+func returnMemory(ret *Return) int64 {
+	if ret == nil {
+		return 0
+	}
+	return int64(unsafe.Sizeof(*ret)) + stringsMemory(ret.Value) + statusMemory(ret.Status)
+}
+
+func statusMemory(s Status) int64 {
+	return int64(len(s.Status) + len(s.StartTime) + len(s.EndTime) + len(s.Elapsed) + len(s.Message))
+}
+
+func stringsMemory(values []string) int64 {
+	n := int64(len(values)) * int64(unsafe.Sizeof(""))
+	for _, v := range values {
+		n += int64(len(v))
+	}
+	return n
+}
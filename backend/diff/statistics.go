@@ -0,0 +1,106 @@
+package robodiff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ComputeStatistics counts the tests of a suite tree the way Robot fills in
+// <statistics>: all tests, one stat per tag (sorted by name, ignoring case,
+// spaces and underscores) and one per suite in document order.
+func ComputeStatistics(root *Suite) *Statistics {
+	var total statCount
+	// Tags are counted under their normalized name, so "Smoke" and "smoke"
+	// are one stat shown with the spelling seen first.
+	tags := make(map[string]*statCount)
+	tagNames := make(map[string]string)
+	var tagKeys []string
+	type suiteStat struct {
+		id, name, longName string
+		count              statCount
+	}
+	var suites []*suiteStat
+
+	var walk func(suite *Suite, id, prefix string) statCount
+	walk = func(suite *Suite, id, prefix string) statCount {
+		longName := suite.Name
+		if prefix != "" {
+			longName = prefix + "." + suite.Name
+		}
+		st := &suiteStat{id: id, name: suite.Name, longName: longName}
+		suites = append(suites, st)
+		for i := range suite.Suites {
+			child := walk(&suite.Suites[i], fmt.Sprintf("%s-s%d", id, i+1), longName)
+			st.count.pass += child.pass
+			st.count.fail += child.fail
+			st.count.skip += child.skip
+		}
+		for _, test := range suite.Tests {
+			st.count.add(test.Status.Status)
+			total.add(test.Status.Status)
+			seen := make(map[string]bool, len(test.Tags))
+			for _, tag := range test.Tags {
+				key := normalizeTag(tag)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				c := tags[key]
+				if c == nil {
+					c = &statCount{}
+					tags[key] = c
+					tagNames[key] = tag
+					tagKeys = append(tagKeys, key)
+				}
+				c.add(test.Status.Status)
+			}
+		}
+		return st.count
+	}
+	walk(root, "s1", "")
+	sort.Strings(tagKeys)
+
+	out := &Statistics{Total: TotalStats{Stats: []Stat{total.stat("All Tests")}}}
+	for _, key := range tagKeys {
+		out.Tags = append(out.Tags, tags[key].stat(tagNames[key]))
+	}
+	for _, st := range suites {
+		stat := st.count.stat(st.longName)
+		stat.ID, stat.SuiteName = st.id, st.name
+		out.Suites = append(out.Suites, stat)
+	}
+	return out
+}
+
+// TagStatistics returns the per-tag counts of the run: the <tag> statistics
+// Robot wrote, or counts computed from the tests when the statistics are
+// missing or no longer match the tree (JUnit input, merged runs, tag
+// filters).
+func (r *Robot) TagStatistics() []Stat {
+	if r == nil {
+		return nil
+	}
+	if r.Statistics != nil {
+		return r.Statistics.Tags
+	}
+	return ComputeStatistics(&r.Suite).Tags
+}
+
+type statCount struct {
+	pass, fail, skip int
+}
+
+func (c *statCount) add(status string) {
+	switch NormalizeStatus(status) {
+	case "PASS":
+		c.pass++
+	case "FAIL":
+		c.fail++
+	default:
+		c.skip++
+	}
+}
+
+func (c statCount) stat(name string) Stat {
+	return Stat{Pass: c.pass, Fail: c.fail, Skip: c.skip, Name: name}
+}
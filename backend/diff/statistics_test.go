@@ -0,0 +1,33 @@
+package robodiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeStatisticsNormalizesTags(t *testing.T) {
+	robot := identityRobot(
+		identitySuite("Login", "/t/login.robot", "Valid Login", "Invalid Login"),
+		identitySuite("Reports", "/t/reports.robot", "Export"),
+	)
+	login := robot.Suite.Suites[0].Tests
+	login[0].Tags = []string{"Smoke", "smoke", "API"}
+	login[1].Tags = []string{"smoke", "Slow_Test"}
+	login[1].Status.Status = "FAIL"
+	export := &robot.Suite.Suites[1].Tests[0]
+	export.Tags = []string{"SMOKE", "slow test"}
+	export.Status.Status = "SKIP"
+
+	stats := ComputeStatistics(&robot.Suite)
+	want := []Stat{
+		{Name: "API", Pass: 1},
+		{Name: "Slow_Test", Fail: 1, Skip: 1},
+		{Name: "Smoke", Pass: 1, Fail: 1, Skip: 1},
+	}
+	if !reflect.DeepEqual(stats.Tags, want) {
+		t.Errorf("tag statistics:\n got %+v\nwant %+v", stats.Tags, want)
+	}
+	if got := stats.Total.Stats[0]; got.Pass != 1 || got.Fail != 1 || got.Skip != 1 {
+		t.Errorf("total %+v, want 1 passed, 1 failed and 1 skipped", got)
+	}
+}
@@ -0,0 +1,479 @@
+package robodiff
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ParseOptions controls how much of each test body the streaming parser keeps.
+// The zero value keeps everything.
+type ParseOptions struct {
+	// MaxKeywordDepth drops everything nested deeper than this many levels
+	// below a test (IF/FOR blocks and their branches/iterations count as a
+	// level, like in the UI tree). Zero keeps all levels.
+	MaxKeywordDepth int
+	// DropPassingBodies drops the whole keyword body of passing tests.
+	DropPassingBodies bool
+	// IndexOnly keeps only each test's header (name, tags, doc, status) and
+	// its byte range; bodies are skipped without being decoded and can be
+	// loaded later with ReadTestAtContext.
+	IndexOnly bool
+}
+
+func (o ParseOptions) truncates() bool {
+	return o.MaxKeywordDepth > 0 || o.DropPassingBodies
+}
+
+const streamBufferSize = 256 * 1024
+
+// ParseRobotXMLReaderContext builds a Robot from r one element at a time. The
+// context is checked before each suite and test, and test bodies are trimmed
+// according to opts as soon as each test has been decoded, so memory grows with
+// the retained tree rather than with the size of the input.
+func ParseRobotXMLReaderContext(ctx context.Context, r io.Reader, opts ParseOptions) (*Robot, error) {
+	d := xml.NewDecoder(bufio.NewReaderSize(r, streamBufferSize))
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("invalid xml: missing <robot> element")
+			}
+			return nil, err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			if se.Name.Local != "robot" {
+				return nil, errors.New("invalid xml: root element is not <robot>")
+			}
+			return streamRobot(ctx, d, se, opts)
+		}
+	}
+}
+
+func streamRobot(ctx context.Context, d *xml.Decoder, start xml.StartElement, opts ParseOptions) (*Robot, error) {
+	robot := &Robot{XMLName: start.Name}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return robot, nil
+			}
+			return nil, err
+		}
+		switch se := tok.(type) {
+		case xml.StartElement:
+			switch se.Name.Local {
+			case "suite":
+				suite, err := streamSuite(ctx, d, se, opts)
+				if err != nil {
+					return nil, err
+				}
+				robot.Suite = suite
+			case "statistics":
+				var stats Statistics
+				if err := d.DecodeElement(&stats, &se); err != nil {
+					return nil, err
+				}
+				robot.Statistics = &stats
+			case "errors":
+				msgs, err := decodeErrors(d, se)
+				if err != nil {
+					return nil, err
+				}
+				robot.Errors = msgs
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if se.Name.Local == start.Name.Local {
+				return robot, nil
+			}
+		}
+	}
+}
+
+func streamSuite(ctx context.Context, d *xml.Decoder, start xml.StartElement, opts ParseOptions) (Suite, error) {
+	var suite Suite
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "name":
+			suite.Name = a.Value
+		case "source":
+			suite.Source = a.Value
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return Suite{}, err
+		}
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return suite, nil
+			}
+			return Suite{}, err
+		}
+		switch se := tok.(type) {
+		case xml.StartElement:
+			switch se.Name.Local {
+			case "suite":
+				child, err := streamSuite(ctx, d, se, opts)
+				if err != nil {
+					return Suite{}, err
+				}
+				suite.Suites = append(suite.Suites, child)
+			case "test":
+				var test Test
+				if opts.IndexOnly {
+					if test, err = decodeTestHeader(d, se); err != nil {
+						return Suite{}, err
+					}
+				} else {
+					if err := d.DecodeElement(&test, &se); err != nil {
+						return Suite{}, err
+					}
+					if opts.truncates() {
+						test.truncateBody(opts)
+					}
+				}
+				test.Offset = offset
+				test.Length = d.InputOffset() - offset
+				suite.Tests = append(suite.Tests, test)
+			case "status":
+				var st Status
+				if err := d.DecodeElement(&st, &se); err != nil {
+					return Suite{}, err
+				}
+				suite.Status = st
+			default:
+				if err := suite.decodeHeaderElement(d, se); err != nil {
+					return Suite{}, err
+				}
+			}
+		case xml.EndElement:
+			if se.Name.Local == start.Name.Local {
+				return suite, nil
+			}
+		}
+	}
+}
+
+// decodeHeaderElement decodes the children of <suite> other than suites,
+// tests and status: doc, metadata and the setup/teardown keywords. Anything
+// else is skipped.
+func (s *Suite) decodeHeaderElement(d *xml.Decoder, se xml.StartElement) error {
+	switch se.Name.Local {
+	case "doc":
+		return d.DecodeElement(&s.Doc, &se)
+	case "meta":
+		var meta Metadata
+		if err := d.DecodeElement(&meta, &se); err != nil {
+			return err
+		}
+		s.Metadata = append(s.Metadata, meta)
+		return nil
+	case "metadata":
+		// Robot < 4 wraps metadata in <metadata><item name="..">..</item></metadata>.
+		var wrapped struct {
+			Items []Metadata `xml:"item"`
+		}
+		if err := d.DecodeElement(&wrapped, &se); err != nil {
+			return err
+		}
+		s.Metadata = append(s.Metadata, wrapped.Items...)
+		return nil
+	case "kw":
+		kind := strings.ToUpper(attrValue(se, "type"))
+		if kind != "SETUP" && kind != "TEARDOWN" {
+			return d.Skip()
+		}
+		kw := &Keyword{}
+		if err := d.DecodeElement(kw, &se); err != nil {
+			return err
+		}
+		kw.Type = kind
+		if kind == "SETUP" {
+			s.Setup = kw
+		} else {
+			s.Teardown = kw
+		}
+		return nil
+	}
+	return d.Skip()
+}
+
+// decodeTestHeader reads a <test> element keeping only its header fields.
+func decodeTestHeader(d *xml.Decoder, start xml.StartElement) (Test, error) {
+	var t Test
+	t.decodeAttrs(start)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return Test{}, err
+		}
+		switch se := tok.(type) {
+		case xml.StartElement:
+			handled, err := t.decodeHeaderElement(d, se)
+			if err != nil {
+				return Test{}, err
+			}
+			if !handled {
+				t.BodyTruncated = true
+				if err := d.Skip(); err != nil {
+					return Test{}, err
+				}
+			}
+		case xml.EndElement:
+			if se.Name.Local == start.Name.Local {
+				return t, nil
+			}
+		}
+	}
+}
+
+// ReadTestAtContext decodes the single <test> element stored at
+// [offset, offset+length) in r, as recorded by the streaming parser.
+func ReadTestAtContext(ctx context.Context, r io.ReaderAt, offset, length int64) (*Test, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	d := xml.NewDecoder(bufio.NewReaderSize(io.NewSectionReader(r, offset, length), streamBufferSize))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("invalid xml: no <test> at recorded offset")
+			}
+			return nil, err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			if se.Name.Local != "test" {
+				return nil, errors.New("invalid xml: no <test> at recorded offset")
+			}
+			var test Test
+			if err := d.DecodeElement(&test, &se); err != nil {
+				return nil, err
+			}
+			test.Offset = offset
+			test.Length = length
+			return &test, nil
+		}
+	}
+}
+
+// FindTestInReaderContext streams r and decodes only the requested test. A
+// case-insensitive match on the dotted long name wins; otherwise the first test
+// whose own name matches is returned.
+func FindTestInReaderContext(ctx context.Context, r io.Reader, name string) (*Test, error) {
+	d := xml.NewDecoder(bufio.NewReaderSize(r, streamBufferSize))
+	var suites []string
+	var byName *Test
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		switch se := tok.(type) {
+		case xml.StartElement:
+			switch se.Name.Local {
+			case "robot":
+			case "suite":
+				suites = append(suites, attrValue(se, "name"))
+			case "test":
+				testName := attrValue(se, "name")
+				longName := strings.Join(append(suites[:len(suites):len(suites)], testName), ".")
+				fullMatch := strings.EqualFold(longName, name)
+				if !fullMatch && (byName != nil || !strings.EqualFold(testName, name)) {
+					if err := d.Skip(); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				var test Test
+				if err := d.DecodeElement(&test, &se); err != nil {
+					return nil, err
+				}
+				if fullMatch {
+					return &test, nil
+				}
+				byName = &test
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if se.Name.Local == "suite" && len(suites) > 0 {
+				suites = suites[:len(suites)-1]
+			}
+		}
+	}
+	if byName != nil {
+		return byName, nil
+	}
+	return nil, nil
+}
+
+func attrValue(se xml.StartElement, name string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// --- Body truncation ---
+
+func (t *Test) truncateBody(opts ParseOptions) {
+	if opts.DropPassingBodies && strings.EqualFold(t.Status.Status, "PASS") {
+		if len(t.Body) > 0 || len(t.Keywords) > 0 || len(t.Ifs) > 0 || len(t.Fors) > 0 {
+			t.Keywords, t.Ifs, t.Fors, t.Body = nil, nil, nil, nil
+			t.BodyTruncated = true
+		}
+		return
+	}
+	if opts.MaxKeywordDepth <= 0 {
+		return
+	}
+	tr := bodyTruncator{max: opts.MaxKeywordDepth}
+	tr.body(t.Body, 1)
+	tr.lists(t.Keywords, t.Ifs, t.Fors, 1)
+	t.BodyTruncated = tr.dropped
+}
+
+// bodyTruncator walks both the ordered Body and the legacy per-type slices,
+// since they hold separate copies of each element.
+type bodyTruncator struct {
+	max     int
+	dropped bool
+}
+
+func (tr *bodyTruncator) body(items []BodyItem, depth int) {
+	for _, it := range items {
+		switch {
+		case it.Keyword != nil:
+			tr.keyword(it.Keyword, depth)
+		case it.If != nil:
+			tr.ifBlock(it.If, depth)
+		case it.For != nil:
+			tr.forBlock(it.For, depth)
+		case it.While != nil:
+			it.While.Iter = tr.iterations(it.While.Iter, depth)
+		case it.Try != nil:
+			it.Try.Branches = tr.branches(it.Try.Branches, depth)
+		case it.Group != nil:
+			tr.group(it.Group, depth)
+		}
+	}
+}
+
+func (tr *bodyTruncator) lists(kws []Keyword, ifs []If, fors []For, depth int) {
+	for i := range kws {
+		tr.keyword(&kws[i], depth)
+	}
+	for i := range ifs {
+		tr.ifBlock(&ifs[i], depth)
+	}
+	for i := range fors {
+		tr.forBlock(&fors[i], depth)
+	}
+}
+
+func (tr *bodyTruncator) keyword(k *Keyword, depth int) {
+	if depth >= tr.max {
+		if len(k.Body) > 0 || len(k.Keywords) > 0 || len(k.Ifs) > 0 || len(k.Fors) > 0 {
+			tr.dropped = true
+		}
+		k.Keywords, k.Ifs, k.Fors, k.Body = nil, nil, nil, nil
+		return
+	}
+	tr.body(k.Body, depth+1)
+	tr.lists(k.Keywords, k.Ifs, k.Fors, depth+1)
+}
+
+func (tr *bodyTruncator) group(g *Group, depth int) {
+	if depth >= tr.max {
+		if len(g.Body) > 0 {
+			tr.dropped = true
+		}
+		g.Body = nil
+		return
+	}
+	tr.body(g.Body, depth+1)
+}
+
+func (tr *bodyTruncator) ifBlock(ifblk *If, depth int) {
+	ifblk.Branches = tr.branches(ifblk.Branches, depth)
+}
+
+// branches trims the branches of an IF or TRY block at depth and returns the
+// (possibly dropped) slice.
+func (tr *bodyTruncator) branches(branches []Branch, depth int) []Branch {
+	if depth >= tr.max {
+		if len(branches) > 0 {
+			tr.dropped = true
+		}
+		return nil
+	}
+	for i := range branches {
+		br := &branches[i]
+		if depth+1 >= tr.max {
+			if len(br.Body) > 0 || len(br.Keywords) > 0 || len(br.Ifs) > 0 || len(br.Fors) > 0 {
+				tr.dropped = true
+			}
+			br.Keywords, br.Ifs, br.Fors, br.Body = nil, nil, nil, nil
+			continue
+		}
+		tr.body(br.Body, depth+2)
+		tr.lists(br.Keywords, br.Ifs, br.Fors, depth+2)
+	}
+	return branches
+}
+
+func (tr *bodyTruncator) forBlock(forblk *For, depth int) {
+	forblk.Iter = tr.iterations(forblk.Iter, depth)
+}
+
+// iterations trims the iterations of a FOR or WHILE loop at depth and returns
+// the (possibly dropped) slice.
+func (tr *bodyTruncator) iterations(iters []Iter, depth int) []Iter {
+	if depth >= tr.max {
+		if len(iters) > 0 {
+			tr.dropped = true
+		}
+		return nil
+	}
+	for i := range iters {
+		it := &iters[i]
+		if depth+1 >= tr.max {
+			if len(it.Body) > 0 || len(it.Keywords) > 0 || len(it.Ifs) > 0 || len(it.Fors) > 0 {
+				tr.dropped = true
+			}
+			it.Keywords, it.Ifs, it.Fors, it.Body = nil, nil, nil, nil
+			continue
+		}
+		tr.body(it.Body, depth+2)
+		tr.lists(it.Keywords, it.Ifs, it.Fors, depth+2)
+	}
+	return iters
+}
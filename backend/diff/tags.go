@@ -0,0 +1,179 @@
+package robodiff
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TagFilter selects tests by tag the same way `robot --include/--exclude` does:
+// matching ignores case, spaces and underscores, '*' and '?' are wildcards and
+// a pattern may combine tags with AND (or '&'), OR and NOT. The operators are
+// upper case; NOT binds loosest and AND tightest, so `a NOT b OR c` matches
+// tests tagged a and neither b nor c. A test is kept when it matches any
+// include pattern (or there are none) and no exclude pattern.
+type TagFilter struct {
+	include []tagPattern
+	exclude []tagPattern
+}
+
+// tagPattern is one compiled pattern: tags must match one alternative of
+// match (all parts of it) and none of not. A pattern starting with NOT has no
+// match alternatives and matches any tags that none of not matches.
+type tagPattern struct {
+	match [][]*regexp.Regexp
+	not   [][][]*regexp.Regexp
+}
+
+func NewTagFilter(include, exclude []string) *TagFilter {
+	return &TagFilter{
+		include: compileTagPatterns(include),
+		exclude: compileTagPatterns(exclude),
+	}
+}
+
+// Empty reports whether the filter would keep every test.
+func (f *TagFilter) Empty() bool {
+	return f == nil || len(f.include) == 0 && len(f.exclude) == 0
+}
+
+func (f *TagFilter) Match(tags []string) bool {
+	if f.Empty() {
+		return true
+	}
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = normalizeTag(tag)
+	}
+	if len(f.include) > 0 && !matchAnyPattern(f.include, normalized) {
+		return false
+	}
+	return !matchAnyPattern(f.exclude, normalized)
+}
+
+// Apply returns a copy of robot that only contains matching tests. Suites left
+// without tests are dropped, except the root suite. Statistics are cleared
+// because they no longer describe the filtered tree.
+func (f *TagFilter) Apply(robot *Robot) *Robot {
+	if f.Empty() || robot == nil {
+		return robot
+	}
+	out := *robot
+	out.Statistics = nil
+	out.Suite = f.filterSuite(&robot.Suite)
+	return &out
+}
+
+func (f *TagFilter) filterSuite(suite *Suite) Suite {
+	out := *suite
+	out.Tests = nil
+	out.Suites = nil
+	for _, test := range suite.Tests {
+		if f.Match(test.Tags) {
+			out.Tests = append(out.Tests, test)
+		}
+	}
+	for i := range suite.Suites {
+		child := f.filterSuite(&suite.Suites[i])
+		if len(child.Tests) > 0 || len(child.Suites) > 0 {
+			out.Suites = append(out.Suites, child)
+		}
+	}
+	return out
+}
+
+func matchAnyPattern(patterns []tagPattern, tags []string) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p tagPattern) matches(tags []string) bool {
+	if len(p.match) > 0 && !matchAnyAlternative(p.match, tags) {
+		return false
+	}
+	for _, not := range p.not {
+		if matchAnyAlternative(not, tags) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAnyAlternative(alternatives [][]*regexp.Regexp, tags []string) bool {
+	for _, parts := range alternatives {
+		if matchAllParts(parts, tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAllParts(parts []*regexp.Regexp, tags []string) bool {
+	for _, part := range parts {
+		found := false
+		for _, tag := range tags {
+			if part.MatchString(tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// compileTagPatterns compiles patterns the way Robot splits them: first at
+// NOT, then each side at OR and each alternative at AND or '&'. Parts that are
+// empty once normalized are ignored.
+func compileTagPatterns(patterns []string) []tagPattern {
+	var out []tagPattern
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		sides := strings.Split(pattern, "NOT")
+		compiled := tagPattern{match: compileTagAlternatives(sides[0])}
+		for _, side := range sides[1:] {
+			if not := compileTagAlternatives(side); len(not) > 0 {
+				compiled.not = append(compiled.not, not)
+			}
+		}
+		if len(compiled.match) > 0 || len(compiled.not) > 0 {
+			out = append(out, compiled)
+		}
+	}
+	return out
+}
+
+func compileTagAlternatives(pattern string) [][]*regexp.Regexp {
+	var out [][]*regexp.Regexp
+	for _, alternative := range strings.Split(pattern, "OR") {
+		var parts []*regexp.Regexp
+		for _, part := range tagAndSeparator.Split(alternative, -1) {
+			part = normalizeTag(part)
+			if part == "" {
+				continue
+			}
+			expr := regexp.QuoteMeta(part)
+			expr = strings.ReplaceAll(expr, `\*`, `.*`)
+			expr = strings.ReplaceAll(expr, `\?`, `.`)
+			parts = append(parts, regexp.MustCompile("^"+expr+"$"))
+		}
+		if len(parts) > 0 {
+			out = append(out, parts)
+		}
+	}
+	return out
+}
+
+var tagAndSeparator = regexp.MustCompile(`AND|&`)
+
+func normalizeTag(tag string) string {
+	tag = strings.ToLower(tag)
+	return strings.NewReplacer(" ", "", "_", "", "\t", "").Replace(tag)
+}
@@ -0,0 +1,80 @@
+package robodiff
+
+import "testing"
+
+func TestTagFilterMatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		tags             []string
+		want             bool
+	}{
+		{"no patterns", nil, nil, []string{"smoke"}, true},
+		{"blank patterns", []string{" "}, []string{""}, nil, true},
+		{"include", []string{"smoke"}, nil, []string{"api", "smoke"}, true},
+		{"include missing", []string{"smoke"}, nil, []string{"api"}, false},
+		{"untagged test not included", []string{"smoke"}, nil, nil, false},
+		{"case spaces and underscores ignored", []string{"Smoke Test"}, nil, []string{"smoke_test"}, true},
+		{"star wildcard", []string{"feat-*"}, nil, []string{"feat-login"}, true},
+		{"question mark wildcard", []string{"p?"}, nil, []string{"p1"}, true},
+		{"question mark is one character", []string{"p?"}, nil, []string{"p10"}, false},
+		{"any include pattern", []string{"api", "smoke"}, nil, []string{"smoke"}, true},
+		{"AND", []string{"smoke AND api"}, nil, []string{"api", "smoke"}, true},
+		{"AND missing one", []string{"smoke AND api"}, nil, []string{"smoke"}, false},
+		{"AND without spaces", []string{"smokeANDapi"}, nil, []string{"api", "smoke"}, true},
+		{"ampersand", []string{"smoke&api"}, nil, []string{"smoke"}, false},
+		{"lower case and is a tag", []string{"smoke and api"}, nil, []string{"smokeandapi"}, true},
+		{"OR", []string{"smoke OR api"}, nil, []string{"api"}, true},
+		{"OR neither", []string{"smoke OR api"}, nil, []string{"ui"}, false},
+		{"AND binds tighter than OR", []string{"a AND b OR c"}, nil, []string{"c"}, true},
+		{"AND binds tighter than OR, missing part", []string{"a OR b AND c"}, nil, []string{"b"}, false},
+		{"NOT", []string{"smoke NOT slow"}, nil, []string{"smoke"}, true},
+		{"NOT excluded", []string{"smoke NOT slow"}, nil, []string{"smoke", "slow"}, false},
+		{"NOT several", []string{"smoke NOT slow NOT wip"}, nil, []string{"smoke", "wip"}, false},
+		{"NOT binds looser than OR", []string{"a NOT b OR c"}, nil, []string{"a", "c"}, false},
+		{"NOT with AND", []string{"a NOT b AND c"}, nil, []string{"a", "b"}, true},
+		{"leading NOT", []string{"NOT slow"}, nil, nil, true},
+		{"leading NOT excluded", []string{"NOT slow"}, nil, []string{"slow"}, false},
+		{"exclude", nil, []string{"wip"}, []string{"smoke", "wip"}, false},
+		{"exclude other", nil, []string{"wip"}, []string{"smoke"}, true},
+		{"exclude wins over include", []string{"smoke"}, []string{"wip"}, []string{"smoke", "wip"}, false},
+		{"exclude with OR", nil, []string{"wip OR flaky"}, []string{"flaky"}, false},
+		{"exclude with AND", nil, []string{"wip AND flaky"}, []string{"flaky"}, true},
+		{"exclude with NOT", nil, []string{"slow NOT nightly"}, []string{"slow", "nightly"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewTagFilter(tt.include, tt.exclude)
+			if got := f.Match(tt.tags); got != tt.want {
+				t.Errorf("include %q exclude %q: Match(%q) = %v, want %v", tt.include, tt.exclude, tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagFilterApply(t *testing.T) {
+	robot := identityRobot(
+		identitySuite("Login", "/t/login.robot", "Valid Login", "Invalid Login"),
+		identitySuite("Reports", "/t/reports.robot", "Export"),
+	)
+	robot.Suite.Suites[0].Tests[0].Tags = []string{"smoke"}
+	robot.Suite.Suites[1].Tests[0].Tags = []string{"Smoke", "slow"}
+	robot.Statistics = ComputeStatistics(&robot.Suite)
+
+	filtered := NewTagFilter([]string{"smoke NOT slow"}, nil).Apply(robot)
+	if _, _, _, total := CountTests(&filtered.Suite); total != 1 || findTestInRobot(filtered, "Tests.Login.Valid Login") == nil {
+		t.Errorf("filtered to %d tests, want only Tests.Login.Valid Login", total)
+	}
+	if len(filtered.Suite.Suites) != 1 {
+		t.Errorf("suite without matching tests kept: %d suites", len(filtered.Suite.Suites))
+	}
+	if filtered.Statistics != nil {
+		t.Errorf("filtered result kept its statistics")
+	}
+	if len(robot.Suite.Suites) != 2 || len(robot.Suite.Suites[0].Tests) != 2 || robot.Statistics == nil {
+		t.Errorf("input changed by Apply")
+	}
+	if got := NewTagFilter(nil, nil).Apply(robot); got != robot {
+		t.Errorf("empty filter copied the result")
+	}
+}
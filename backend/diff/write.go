@@ -0,0 +1,348 @@
+package robodiff
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteRobotXML writes robot as an output.xml in the Robot Framework 7 schema
+// (version 5), so rebot and this package can read it again. Times are
+// converted to the RF 7 start/elapsed form and statistics are recomputed from
+// the suite tree. Only what the model holds is written: the ids are
+// renumbered and test line numbers, for example, are not part of it.
+func WriteRobotXML(w io.Writer, robot *Robot) error {
+	bw := bufio.NewWriter(w)
+	if _, err := io.WriteString(bw, xml.Header); err != nil {
+		return err
+	}
+	rw := &robotWriter{enc: xml.NewEncoder(bw)}
+	rw.enc.Indent("", "  ")
+	rw.start("robot",
+		"generator", "Robodiff",
+		"generated", time.Now().Format(robotXMLTimeLayout),
+		"rpa", "false",
+		"schemaversion", "5")
+	rw.suite(&robot.Suite, "s1")
+	rw.statistics(&robot.Suite)
+	rw.start("errors")
+	rw.messages(robot.Errors)
+	rw.end("errors")
+	rw.end("robot")
+	if rw.err == nil {
+		rw.err = rw.enc.Flush()
+	}
+	if rw.err != nil {
+		return rw.err
+	}
+	if _, err := io.WriteString(bw, "\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+const robotXMLTimeLayout = "2006-01-02T15:04:05.000000"
+
+// robotWriter encodes the model with xml.Encoder, keeping the first error so
+// callers don't have to check every token.
+type robotWriter struct {
+	enc *xml.Encoder
+	err error
+}
+
+func (rw *robotWriter) token(tok xml.Token) {
+	if rw.err == nil {
+		rw.err = rw.enc.EncodeToken(tok)
+	}
+}
+
+// start opens an element with attributes given as name/value pairs; empty
+// values are left out.
+func (rw *robotWriter) start(name string, attrs ...string) {
+	se := xml.StartElement{Name: xml.Name{Local: name}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] != "" {
+			se.Attr = append(se.Attr, xml.Attr{Name: xml.Name{Local: attrs[i]}, Value: attrs[i+1]})
+		}
+	}
+	rw.token(se)
+}
+
+func (rw *robotWriter) end(name string) {
+	rw.token(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func (rw *robotWriter) text(s string) {
+	if s != "" {
+		rw.token(xml.CharData(s))
+	}
+}
+
+// element writes <name attrs...>text</name>.
+func (rw *robotWriter) element(name, text string, attrs ...string) {
+	rw.start(name, attrs...)
+	rw.text(text)
+	rw.end(name)
+}
+
+func (rw *robotWriter) suite(suite *Suite, id string) {
+	rw.start("suite", "id", id, "name", suite.Name, "source", suite.Source)
+	if suite.Setup != nil {
+		rw.keyword(suite.Setup)
+	}
+	for i := range suite.Suites {
+		rw.suite(&suite.Suites[i], fmt.Sprintf("%s-s%d", id, i+1))
+	}
+	for i := range suite.Tests {
+		rw.test(&suite.Tests[i], fmt.Sprintf("%s-t%d", id, i+1))
+	}
+	if suite.Teardown != nil {
+		rw.keyword(suite.Teardown)
+	}
+	if suite.Doc != "" {
+		rw.element("doc", suite.Doc)
+	}
+	for _, meta := range suite.Metadata {
+		rw.element("meta", meta.Value, "name", meta.Name)
+	}
+	rw.status(suite.Status)
+	rw.end("suite")
+}
+
+func (rw *robotWriter) test(test *Test, id string) {
+	rw.start("test", "id", id, "name", test.Name)
+	rw.body(test.Body, test.Keywords, test.Ifs, test.Fors)
+	for _, tag := range test.Tags {
+		rw.element("tag", tag)
+	}
+	if test.Doc != "" {
+		rw.element("doc", test.Doc)
+	}
+	if test.Timeout != "" {
+		rw.start("timeout", "value", test.Timeout)
+		rw.end("timeout")
+	}
+	rw.status(test.Status)
+	rw.end("test")
+}
+
+// body writes an ordered body, or the per-type lists for models that were
+// built without one.
+func (rw *robotWriter) body(items []BodyItem, kws []Keyword, ifs []If, fors []For) {
+	if len(items) > 0 {
+		for _, item := range items {
+			rw.bodyItem(item)
+		}
+		return
+	}
+	for i := range kws {
+		rw.keyword(&kws[i])
+	}
+	for i := range ifs {
+		rw.ifBlock("if", ifs[i].Branches, ifs[i].Status)
+	}
+	for i := range fors {
+		rw.forBlock(&fors[i])
+	}
+}
+
+func (rw *robotWriter) bodyItem(item BodyItem) {
+	switch {
+	case item.Keyword != nil:
+		rw.keyword(item.Keyword)
+	case item.If != nil:
+		rw.ifBlock("if", item.If.Branches, item.If.Status)
+	case item.For != nil:
+		rw.forBlock(item.For)
+	case item.While != nil:
+		w := item.While
+		rw.start("while", "condition", w.Condition, "limit", w.Limit, "on_limit", w.OnLimit, "on_limit_message", w.OnLimitMessage)
+		rw.iterations(w.Iter)
+		rw.status(w.Status)
+		rw.end("while")
+	case item.Try != nil:
+		rw.ifBlock("try", item.Try.Branches, item.Try.Status)
+	case item.Break != nil:
+		rw.start("break")
+		rw.status(item.Break.Status)
+		rw.end("break")
+	case item.Continue != nil:
+		rw.start("continue")
+		rw.status(item.Continue.Status)
+		rw.end("continue")
+	case item.Var != nil:
+		v := item.Var
+		rw.start("variable", "name", v.Name, "scope", v.Scope, "separator", v.Separator)
+		for _, value := range v.Value {
+			rw.element("var", value)
+		}
+		rw.status(v.Status)
+		rw.end("variable")
+	case item.Group != nil:
+		rw.start("group", "name", item.Group.Name)
+		rw.body(item.Group.Body, nil, nil, nil)
+		rw.status(item.Group.Status)
+		rw.end("group")
+	case item.Return != nil:
+		rw.returnItem(item.Return)
+	}
+}
+
+func (rw *robotWriter) keyword(kw *Keyword) {
+	line := ""
+	if kw.Line > 0 {
+		line = strconv.Itoa(kw.Line)
+	}
+	rw.start("kw", "name", kw.Name, "owner", kw.Owner, "source_name", kw.SourceName, "type", keywordTypeAttr(kw.Type),
+		"source", kw.Source, "line", line)
+	for _, v := range kw.Assign {
+		rw.element("var", v)
+	}
+	for _, arg := range kw.Arguments {
+		rw.element("arg", arg)
+	}
+	for _, tag := range kw.Tags {
+		rw.element("tag", tag)
+	}
+	if kw.Doc != "" {
+		rw.element("doc", kw.Doc)
+	}
+	if kw.Timeout != "" {
+		rw.element("timeout", "", "value", kw.Timeout)
+	}
+	rw.messages(kw.Messages)
+	rw.body(kw.Body, kw.Keywords, kw.Ifs, kw.Fors)
+	rw.status(kw.Status)
+	rw.end("kw")
+}
+
+func (rw *robotWriter) messages(msgs []Message) {
+	for _, msg := range msgs {
+		html := ""
+		if msg.HTML {
+			html = "true"
+		}
+		rw.element("msg", msg.Text, "time", robotXMLTime(msg.Timestamp), "level", msg.Level, "html", html)
+	}
+}
+
+// keywordTypeAttr drops the default type, which RF 7 leaves implicit.
+func keywordTypeAttr(kind string) string {
+	if strings.EqualFold(kind, "KEYWORD") || strings.EqualFold(kind, "KW") {
+		return ""
+	}
+	return kind
+}
+
+// ifBlock writes IF and TRY, which both hold typed branches.
+func (rw *robotWriter) ifBlock(name string, branches []Branch, status Status) {
+	rw.start(name)
+	for i := range branches {
+		b := &branches[i]
+		rw.start("branch", "type", b.Type, "condition", b.Condition, "pattern_type", b.PatternType, "assign", b.Assign)
+		for _, pattern := range b.Patterns {
+			rw.element("pattern", pattern)
+		}
+		rw.body(b.Body, b.Keywords, b.Ifs, b.Fors)
+		if len(b.Body) == 0 && b.Return != nil {
+			rw.returnItem(b.Return)
+		}
+		rw.status(b.Status)
+		rw.end("branch")
+	}
+	rw.status(status)
+	rw.end(name)
+}
+
+func (rw *robotWriter) forBlock(f *For) {
+	rw.start("for", "flavor", f.Flavor)
+	for _, v := range f.Var {
+		rw.element("var", v)
+	}
+	for _, v := range f.Value {
+		rw.element("value", v)
+	}
+	rw.iterations(f.Iter)
+	rw.status(f.Status)
+	rw.end("for")
+}
+
+func (rw *robotWriter) iterations(iters []Iter) {
+	for i := range iters {
+		it := &iters[i]
+		rw.start("iter")
+		rw.body(it.Body, it.Keywords, it.Ifs, it.Fors)
+		if len(it.Body) == 0 && it.Return != nil {
+			rw.returnItem(it.Return)
+		}
+		rw.status(it.Status)
+		rw.end("iter")
+	}
+}
+
+func (rw *robotWriter) returnItem(r *Return) {
+	rw.start("return")
+	for _, v := range r.Value {
+		rw.element("value", v)
+	}
+	rw.status(r.Status)
+	rw.end("return")
+}
+
+// status writes <status> with RF 7 start/elapsed times, deriving the elapsed
+// time from an RF 6 end time when needed.
+func (rw *robotWriter) status(status Status) {
+	elapsed := strings.TrimSpace(status.Elapsed)
+	if start, end, ok := statusSpan(status); ok && elapsed == "" {
+		elapsed = strconv.FormatFloat(end.Sub(start).Seconds(), 'f', 6, 64)
+	}
+	rw.element("status", status.Message,
+		"status", status.Status,
+		"start", robotXMLTime(status.StartTime),
+		"elapsed", elapsed)
+}
+
+// robotXMLTime converts a Robot timestamp in any supported format to the RF 7
+// form; unparseable values are dropped.
+func robotXMLTime(value string) string {
+	t, ok := ParseRobotTime(value)
+	if !ok {
+		return ""
+	}
+	return t.Format(robotXMLTimeLayout)
+}
+
+func (rw *robotWriter) stat(st Stat, attrs ...string) {
+	attrs = append([]string{
+		"pass", strconv.Itoa(st.Pass),
+		"fail", strconv.Itoa(st.Fail),
+		"skip", strconv.Itoa(st.Skip),
+	}, attrs...)
+	rw.element("stat", st.Name, attrs...)
+}
+
+// statistics writes total, per-tag and per-suite counts like Robot does.
+func (rw *robotWriter) statistics(root *Suite) {
+	stats := ComputeStatistics(root)
+	rw.start("statistics")
+	rw.start("total")
+	for _, st := range stats.Total.Stats {
+		rw.stat(st)
+	}
+	rw.end("total")
+	rw.start("tag")
+	for _, st := range stats.Tags {
+		rw.stat(st)
+	}
+	rw.end("tag")
+	rw.start("suite")
+	for _, st := range stats.Suites {
+		rw.stat(st, "name", st.SuiteName, "id", st.ID)
+	}
+	rw.end("suite")
+	rw.end("statistics")
+}
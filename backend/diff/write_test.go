@@ -0,0 +1,89 @@
+package robodiff
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRobotXMLRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for _, path := range []string{fixtureXML, fixtureJSON} {
+		t.Run(path, func(t *testing.T) {
+			robot, err := ParseResultFileWithOptions(ctx, path, ParseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := WriteRobotXML(&buf, robot); err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectFormat(buf.Bytes()); got != FormatRobotXML {
+				t.Fatalf("written result detected as %q", got)
+			}
+			written, err := ParseResultReaderWithOptions(ctx, &buf, ParseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			clearOffsets(&robot.Suite)
+			clearOffsets(&written.Suite)
+			compareSuites(t, &written.Suite, &robot.Suite)
+			if !reflect.DeepEqual(written.Errors, robot.Errors) {
+				t.Errorf("errors differ:\n got %+v\nwant %+v", written.Errors, robot.Errors)
+			}
+			if want := ComputeStatistics(&robot.Suite); !reflect.DeepEqual(written.Statistics, want) {
+				t.Errorf("statistics differ:\n got %+v\nwant %+v", written.Statistics, want)
+			}
+		})
+	}
+}
+
+// TestWriteRobotXMLBodyItems round-trips the control structures that the
+// fixture run does not use.
+func TestWriteRobotXMLBodyItems(t *testing.T) {
+	pass := Status{Status: "PASS", StartTime: "2024-05-02T10:00:00.000000", Elapsed: "0.001000"}
+	notRun := Status{Status: "NOT RUN", StartTime: "2024-05-02T10:00:00.000000", Elapsed: "0.000000"}
+	kw := func(name string) *Keyword {
+		return &Keyword{Name: name, Owner: "BuiltIn", Source: "/work/common.resource", Line: 12, Status: pass}
+	}
+	body := []BodyItem{
+		{Keyword: kw("No Operation")},
+		{While: &While{Condition: "$i < 2", Limit: "10", OnLimit: "pass", Iter: []Iter{
+			{Body: []BodyItem{{Continue: &Continue{Status: pass}}}, Status: pass},
+			{Body: []BodyItem{{Break: &Break{Status: pass}}}, Status: pass},
+		}, Status: pass}},
+		{Try: &Try{Branches: []Branch{
+			{Type: "TRY", Keywords: []Keyword{*kw("Fail")}, Body: []BodyItem{{Keyword: kw("Fail")}}, Status: pass},
+			{Type: "EXCEPT", Patterns: []string{"Oops*"}, PatternType: "glob", Assign: "${err}", Status: notRun},
+		}, Status: pass}},
+		{Var: &Var{Name: "${items}", Scope: "TEST", Separator: ",", Value: []string{"a", "b"}, Status: pass}},
+		{Group: &Group{Name: "Checks", Body: []BodyItem{{Keyword: kw("Log")}}, Status: pass}},
+		{Return: &Return{Value: []string{"${items}"}, Status: pass}},
+	}
+	test := Test{Name: "Control", Status: pass, Body: body}
+	for _, item := range body {
+		if item.Keyword != nil {
+			test.Keywords = append(test.Keywords, *item.Keyword)
+		}
+	}
+	robot := &Robot{Suite: Suite{Name: "Root", Tests: []Test{test}, Status: pass}}
+
+	var buf bytes.Buffer
+	if err := WriteRobotXML(&buf, robot); err != nil {
+		t.Fatal(err)
+	}
+	written, err := ParseResultReaderWithOptions(context.Background(), &buf, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written.Suite.Tests) != 1 {
+		t.Fatalf("got %d tests, want 1", len(written.Suite.Tests))
+	}
+	got := written.Suite.Tests[0]
+	got.Offset, got.Length = 0, 0
+	if !reflect.DeepEqual(got, test) {
+		t.Errorf("test differs:\n got %+v\nwant %+v", got, test)
+	}
+}
@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	rdiff "robot_diff/backend/diff"
+)
+
+// maxArtifactBytes bounds the images kept by artifactStore; the oldest are
+// evicted first and extracted again from the run when requested.
+const maxArtifactBytes = 256 << 20
+
+// artifactStore keeps the images embedded in HTML messages as data: URIs so
+// the UI can load them by URL instead of receiving them inline. Artifacts
+// are keyed by run and content hash, so the same screenshot logged twice is
+// stored once.
+type artifactStore struct {
+	mu    sync.Mutex
+	items map[string]artifact
+	order []string
+	bytes int64
+}
+
+type artifact struct {
+	contentType string
+	data        []byte
+}
+
+func newArtifactStore() *artifactStore {
+	return &artifactStore{items: make(map[string]artifact)}
+}
+
+// putDataURI stores the image of a base64 data: URI and returns its id.
+// Only raster images are accepted; SVG can carry scripts.
+func (a *artifactStore) putDataURI(runID, uri string) (string, bool) {
+	header, payload, ok := strings.Cut(strings.TrimSpace(uri)[len("data:"):], ",")
+	if !ok {
+		return "", false
+	}
+	contentType, encoding, _ := strings.Cut(header, ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if !strings.HasPrefix(contentType, "image/") || contentType == "image/svg+xml" ||
+		!strings.EqualFold(strings.TrimSpace(encoding), "base64") {
+		return "", false
+	}
+	payload = strings.Join(strings.Fields(payload), "")
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		if data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "=")); err != nil {
+			return "", false
+		}
+	}
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:16])
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := runID + "\x00" + id
+	if _, ok := a.items[key]; ok {
+		return id, true
+	}
+	a.items[key] = artifact{contentType: contentType, data: data}
+	a.order = append(a.order, key)
+	a.bytes += int64(len(data))
+	for a.bytes > maxArtifactBytes && len(a.order) > 1 {
+		oldest := a.order[0]
+		a.order = a.order[1:]
+		a.bytes -= int64(len(a.items[oldest].data))
+		delete(a.items, oldest)
+	}
+	return id, true
+}
+
+func (a *artifactStore) get(runID, id string) (artifact, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	item, ok := a.items[runID+"\x00"+id]
+	return item, ok
+}
+
+func artifactURL(runID, id string) string {
+	return "/api/artifact?runId=" + url.QueryEscape(runID) + "&id=" + url.QueryEscape(id)
+}
+
+// handleArtifact serves an image extracted from an HTML message. Artifacts
+// are extracted when messages are sent to the UI; one that was evicted or
+// predates a restart is looked up again in the run's loaded messages.
+func (s *Server) handleArtifact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	runID := strings.TrimSpace(r.URL.Query().Get("runId"))
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	if runID == "" || id == "" {
+		writeError(w, http.StatusBadRequest, "runId and id required")
+		return
+	}
+
+	item, ok := s.artifacts.get(runID, id)
+	if !ok {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		_, _, robots, err := s.store.GetRuns(ctx, []string{runID})
+		if err != nil {
+			status, code, msg, detail := classifyError(err)
+			writeErrorWithCode(w, status, code, msg, detail)
+			return
+		}
+		mr := s.messageRenderer(runID)
+		forEachRunMessage(robots[0], func(msg rdiff.Message) {
+			if msg.HTML && strings.Contains(msg.Text, "data:") {
+				mr.text(msg)
+			}
+		})
+		if item, ok = s.artifacts.get(runID, id); !ok {
+			writeError(w, http.StatusNotFound, "artifact not found")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", item.contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	_, _ = w.Write(item.data)
+}
+
+// forEachRunMessage calls fn for every message of robot that is in memory:
+// execution errors and the messages of suite fixtures and test bodies.
+func forEachRunMessage(robot *rdiff.Robot, fn func(rdiff.Message)) {
+	for _, msg := range robot.Errors {
+		fn(msg)
+	}
+	var walkKeywords func(keywords []rdiff.Keyword)
+	walkKeywords = func(keywords []rdiff.Keyword) {
+		for _, kw := range keywords {
+			for _, msg := range kw.Messages {
+				fn(msg)
+			}
+			walkKeywords(keywordChildrenInOrder(kw))
+		}
+	}
+	var walkSuite func(suite *rdiff.Suite)
+	walkSuite = func(suite *rdiff.Suite) {
+		for _, kw := range []*rdiff.Keyword{suite.Setup, suite.Teardown} {
+			if kw != nil {
+				walkKeywords([]rdiff.Keyword{*kw})
+			}
+		}
+		for i := range suite.Tests {
+			walkKeywords(buildTestBodyKeywords(&suite.Tests[i]))
+		}
+		for i := range suite.Suites {
+			walkSuite(&suite.Suites[i])
+		}
+	}
+	walkSuite(&robot.Suite)
+}
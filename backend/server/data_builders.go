@@ -52,6 +52,25 @@ func orderedBodyToKeywords(body []rdiff.BodyItem) []rdiff.Keyword {
 			out = append(out, ifToKeyword(*it.If))
 		case it.For != nil:
 			out = append(out, forToKeyword(*it.For))
+		case it.While != nil:
+			out = append(out, whileToKeyword(*it.While))
+		case it.Try != nil:
+			out = append(out, tryToKeyword(*it.Try))
+		case it.Break != nil:
+			out = append(out, rdiff.Keyword{Name: "BREAK", Type: "BREAK", Status: it.Break.Status})
+		case it.Continue != nil:
+			out = append(out, rdiff.Keyword{Name: "CONTINUE", Type: "CONTINUE", Status: it.Continue.Status})
+		case it.Var != nil:
+			out = append(out, varToKeyword(*it.Var))
+		case it.Group != nil:
+			out = append(out, rdiff.Keyword{
+				Name:     strings.TrimSpace("GROUP " + it.Group.Name),
+				Type:     "GROUP",
+				Status:   it.Group.Status,
+				Keywords: orderedBodyToKeywords(it.Group.Body),
+			})
+		case it.Return != nil:
+			out = append(out, returnToKeyword(*it.Return))
 		}
 	}
 	return out
@@ -69,17 +88,39 @@ func controlFlowToKeywords(ifs []rdiff.If, fors []rdiff.For) []rdiff.Keyword {
 }
 
 func ifToKeyword(ifblk rdiff.If) rdiff.Keyword {
-	kw := rdiff.Keyword{
-		Name:   "IF",
-		Type:   "IF",
-		Status: ifblk.Status,
+	return rdiff.Keyword{
+		Name:     "IF",
+		Type:     "IF",
+		Status:   ifblk.Status,
+		Keywords: branchesToKeywords(ifblk.Branches),
+	}
+}
+
+func tryToKeyword(tryblk rdiff.Try) rdiff.Keyword {
+	return rdiff.Keyword{
+		Name:     "TRY",
+		Type:     "TRY",
+		Status:   tryblk.Status,
+		Keywords: branchesToKeywords(tryblk.Branches),
 	}
-	children := make([]rdiff.Keyword, 0, len(ifblk.Branches))
-	for _, br := range ifblk.Branches {
+}
+
+func branchesToKeywords(branches []rdiff.Branch) []rdiff.Keyword {
+	children := make([]rdiff.Keyword, 0, len(branches))
+	for _, br := range branches {
 		name := strings.TrimSpace(br.Type)
 		if br.Condition != "" {
 			name = name + " " + br.Condition
 		}
+		if len(br.Patterns) > 0 {
+			name = name + " " + strings.Join(br.Patterns, ", ")
+		}
+		if br.PatternType != "" {
+			name = name + " type=" + br.PatternType
+		}
+		if br.Assign != "" {
+			name = name + " AS " + br.Assign
+		}
 		bkw := rdiff.Keyword{
 			Name:   name,
 			Type:   "BRANCH",
@@ -96,8 +137,7 @@ func ifToKeyword(ifblk rdiff.If) rdiff.Keyword {
 		}
 		children = append(children, bkw)
 	}
-	kw.Keywords = children
-	return kw
+	return children
 }
 
 func forToKeyword(forblk rdiff.For) rdiff.Keyword {
@@ -112,14 +152,38 @@ func forToKeyword(forblk rdiff.For) rdiff.Keyword {
 		name = name + " " + strings.Join(forblk.Value, ", ")
 	}
 
-	kw := rdiff.Keyword{
-		Name:   name,
-		Type:   "FOR",
-		Status: forblk.Status,
+	return rdiff.Keyword{
+		Name:     name,
+		Type:     "FOR",
+		Status:   forblk.Status,
+		Keywords: itersToKeywords(forblk.Iter),
 	}
+}
 
-	children := make([]rdiff.Keyword, 0, len(forblk.Iter))
-	for i, it := range forblk.Iter {
+func whileToKeyword(whileblk rdiff.While) rdiff.Keyword {
+	name := strings.TrimSpace("WHILE " + whileblk.Condition)
+	var args []string
+	if whileblk.Limit != "" {
+		args = append(args, fmt.Sprintf("limit=%s", whileblk.Limit))
+	}
+	if whileblk.OnLimit != "" {
+		args = append(args, fmt.Sprintf("on_limit=%s", whileblk.OnLimit))
+	}
+	if whileblk.OnLimitMessage != "" {
+		args = append(args, fmt.Sprintf("on_limit_message=%s", whileblk.OnLimitMessage))
+	}
+	return rdiff.Keyword{
+		Name:      name,
+		Type:      "WHILE",
+		Arguments: args,
+		Status:    whileblk.Status,
+		Keywords:  itersToKeywords(whileblk.Iter),
+	}
+}
+
+func itersToKeywords(iters []rdiff.Iter) []rdiff.Keyword {
+	children := make([]rdiff.Keyword, 0, len(iters))
+	for i, it := range iters {
 		iterKw := rdiff.Keyword{
 			Name:   fmt.Sprintf("ITER %d", i+1),
 			Type:   "ITER",
@@ -136,8 +200,23 @@ func forToKeyword(forblk rdiff.For) rdiff.Keyword {
 		}
 		children = append(children, iterKw)
 	}
-	kw.Keywords = children
-	return kw
+	return children
+}
+
+func varToKeyword(v rdiff.Var) rdiff.Keyword {
+	args := append([]string(nil), v.Value...)
+	if v.Scope != "" {
+		args = append(args, fmt.Sprintf("scope=%s", v.Scope))
+	}
+	if v.Separator != "" {
+		args = append(args, fmt.Sprintf("separator=%s", v.Separator))
+	}
+	return rdiff.Keyword{
+		Name:      strings.TrimSpace("VAR " + v.Name),
+		Type:      "VAR",
+		Arguments: args,
+		Status:    v.Status,
+	}
 }
 
 func returnToKeyword(ret rdiff.Return) rdiff.Keyword {
@@ -153,7 +232,7 @@ func returnToKeyword(ret rdiff.Return) rdiff.Keyword {
 	}
 }
 
-func buildKeywordsData(keywords []rdiff.Keyword) []map[string]any {
+func buildKeywordsData(keywords []rdiff.Keyword, mr *messageRenderer) []map[string]any {
 	result := make([]map[string]any, len(keywords))
 	for i, kw := range keywords {
 		children := keywordChildrenInOrder(kw)
@@ -166,8 +245,16 @@ func buildKeywordsData(keywords []rdiff.Keyword) []map[string]any {
 			"start":         kw.Status.StartTime,
 			"end":           kw.Status.EndTime,
 			"arguments":     kw.Arguments,
-			"keywords":      buildKeywordsData(children),
-			"messages":      buildMessagesData(kw.Messages),
+			"owner":         kw.Owner,
+			"sourceName":    kw.SourceName,
+			"source":        kw.Source,
+			"line":          kw.Line,
+			"assign":        nonNilStrings(kw.Assign),
+			"tags":          nonNilStrings(kw.Tags),
+			"doc":           kw.Doc,
+			"timeout":       kw.Timeout,
+			"keywords":      buildKeywordsData(children, mr),
+			"messages":      buildMessagesData(kw.Messages, mr),
 		}
 	}
 	return result
@@ -183,45 +270,114 @@ func keywordChildrenInOrder(kw rdiff.Keyword) []rdiff.Keyword {
 	return children
 }
 
-func buildMessagesData(messages []rdiff.Message) []map[string]any {
+// buildMessagesData returns the messages for the UI; HTML messages are
+// sanitized and their images turned into URLs by mr.
+func buildMessagesData(messages []rdiff.Message, mr *messageRenderer) []map[string]any {
 	result := make([]map[string]any, len(messages))
 	for i, msg := range messages {
 		result[i] = map[string]any{
 			"level":     msg.Level,
 			"timestamp": msg.Timestamp,
 			"html":      msg.HTML,
-			"text":      msg.Text,
+			"text":      mr.text(msg),
+		}
+	}
+	return result
+}
+
+// buildStatisticsData returns the total, per-tag and per-suite statistics of
+// a run: those Robot wrote when they still describe the tree, otherwise
+// counted from the tests.
+func buildStatisticsData(robot *rdiff.Robot) map[string]any {
+	stats := robot.Statistics
+	if stats == nil {
+		stats = rdiff.ComputeStatistics(&robot.Suite)
+	}
+	return map[string]any{
+		"total":  buildStatsData(stats.Total.Stats),
+		"tags":   buildStatsData(stats.Tags),
+		"suites": buildStatsData(stats.Suites),
+	}
+}
+
+func buildStatsData(stats []rdiff.Stat) []map[string]any {
+	result := make([]map[string]any, len(stats))
+	for i, st := range stats {
+		item := map[string]any{
+			"name": st.Name,
+			"pass": st.Pass,
+			"fail": st.Fail,
+			"skip": st.Skip,
+		}
+		if st.ID != "" {
+			item["id"] = st.ID
 		}
+		if st.Doc != "" {
+			item["doc"] = st.Doc
+		}
+		if st.Combined != "" {
+			item["combined"] = st.Combined
+		}
+		result[i] = item
 	}
 	return result
 }
 
-func buildSuitesData(suite *rdiff.Suite) []map[string]any {
+func buildSuitesData(suite *rdiff.Suite, mr *messageRenderer) []map[string]any {
 	var result []map[string]any
 
-	// Add current suite if it has tests
-	if len(suite.Tests) > 0 {
+	// Add current suite if it has tests, fixtures or documentation to show
+	if len(suite.Tests) > 0 || suite.Setup != nil || suite.Teardown != nil ||
+		len(suite.Metadata) > 0 || suite.Doc != "" {
 		tests := make([]map[string]any, len(suite.Tests))
 		for i, test := range suite.Tests {
 			tests[i] = map[string]any{
-				"name":   test.Name,
-				"status": test.Status.Status,
+				"name":    test.Name,
+				"status":  test.Status.Status,
+				"message": strings.TrimSpace(test.Status.Message),
+				"tags":    nonNilStrings(test.Tags),
+			}
+			if test.FirstAttempt != nil {
+				tests[i]["firstAttempt"] = test.FirstAttempt.Status
 			}
 		}
-		result = append(result, map[string]any{
-			"name":  suite.Name,
-			"tests": tests,
-		})
+		data := map[string]any{
+			"name":     suite.Name,
+			"tests":    tests,
+			"status":   suite.Status.Status,
+			"message":  strings.TrimSpace(suite.Status.Message),
+			"doc":      suite.Doc,
+			"source":   suite.Source,
+			"metadata": buildMetadataData(suite.Metadata),
+		}
+		if suite.Setup != nil {
+			data["setup"] = buildKeywordsData([]rdiff.Keyword{*suite.Setup}, mr)[0]
+		}
+		if suite.Teardown != nil {
+			data["teardown"] = buildKeywordsData([]rdiff.Keyword{*suite.Teardown}, mr)[0]
+		}
+		result = append(result, data)
 	}
 
 	// Recursively add sub-suites
 	for i := range suite.Suites {
-		result = append(result, buildSuitesData(&suite.Suites[i])...)
+		result = append(result, buildSuitesData(&suite.Suites[i], mr)...)
 	}
 
 	return result
 }
 
+func buildMetadataData(metadata []rdiff.Metadata) []map[string]any {
+	result := make([]map[string]any, len(metadata))
+	for i, meta := range metadata {
+		result[i] = map[string]any{
+			"name":  meta.Name,
+			"value": meta.Value,
+		}
+	}
+	return result
+}
+
 func buildTimeBreakdownData(suite *rdiff.Suite) (timeBreakdownNode, timeBreakdownSummary) {
 	root := buildTimeBreakdownNode(suite, "")
 	summary := timeBreakdownSummary{
@@ -304,8 +460,8 @@ func buildTimeBreakdownNode(suite *rdiff.Suite, prefix string) timeBreakdownNode
 }
 
 func durationMsFromStatus(status rdiff.Status) int64 {
-	start, okStart := parseRobotTimestamp(status.StartTime)
-	end, okEnd := parseRobotTimestamp(status.EndTime)
+	start, okStart := rdiff.ParseRobotTime(status.StartTime)
+	end, okEnd := rdiff.ParseRobotTime(status.EndTime)
 	if okStart && okEnd && !end.Before(start) {
 		return end.Sub(start).Milliseconds()
 	}
@@ -315,28 +471,6 @@ func durationMsFromStatus(status rdiff.Status) int64 {
 	return 0
 }
 
-func parseRobotTimestamp(raw string) (time.Time, bool) {
-	value := strings.TrimSpace(raw)
-	if value == "" {
-		return time.Time{}, false
-	}
-
-	layouts := []string{
-		"20060102 15:04:05.000",
-		"20060102 15:04:05",
-		time.RFC3339Nano,
-		time.RFC3339,
-	}
-
-	for _, layout := range layouts {
-		parsed, err := time.Parse(layout, value)
-		if err == nil {
-			return parsed, true
-		}
-	}
-	return time.Time{}, false
-}
-
 func parseRobotElapsedMs(raw string) (int64, bool) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
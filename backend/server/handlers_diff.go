@@ -10,8 +10,17 @@ import (
 )
 
 type diffRequest struct {
-	RunIDs []string `json:"runIds"`
-	Title  string   `json:"title"`
+	RunIDs      []string `json:"runIds"`
+	Title       string   `json:"title"`
+	// IncludeTags and ExcludeTags are tag patterns as robot's --include and
+	// --exclude take them.
+	IncludeTags []string `json:"includeTags"`
+	ExcludeTags []string `json:"excludeTags"`
+	// Attempt picks the status of re-executed tests in merged runs: "final"
+	// (default) or "first".
+	Attempt string `json:"attempt"`
+	// ExactNames turns off matching renamed and moved tests.
+	ExactNames bool `json:"exactNames"`
 }
 
 func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
@@ -32,6 +41,12 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 	if req.Title == "" {
 		req.Title = "Robodiff"
 	}
+	switch req.Attempt {
+	case "", rdiff.AttemptFinal, rdiff.AttemptFirst:
+	default:
+		writeError(w, http.StatusBadRequest, "attempt must be final or first")
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
 	defer cancel()
@@ -43,14 +58,19 @@ func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results := rdiff.NewDiffResults()
+	filter := rdiff.NewTagFilter(req.IncludeTags, req.ExcludeTags)
+	results := rdiff.NewDiffResultsWithOptions(rdiff.MatchOptions{ExactNames: req.ExactNames})
 	for i := range robots {
 		if err := ctx.Err(); err != nil {
 			status, code, msg, detail := classifyError(err)
 			writeErrorWithCode(w, status, code, msg, detail)
 			return
 		}
-		results.AddParsedOutput(robots[i], columns[i])
+		robot := robots[i]
+		if req.Attempt == rdiff.AttemptFirst {
+			robot = rdiff.FirstAttempts(robot)
+		}
+		results.AddParsedOutput(filter.Apply(robot), columns[i])
 	}
 
 	reporter := rdiff.NewDiffReporter(req.Title, columns, inputFiles)
@@ -1,10 +1,18 @@
 package backend
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
+
+	rdiff "robot_diff/backend/diff"
 )
 
 func (s *Server) handleRunFile(w http.ResponseWriter, r *http.Request) {
@@ -20,7 +28,7 @@ func (s *Server) handleRunFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	runFile, err := s.store.RunFilePath(runID)
+	src, err := s.store.RunSource(runID)
 	if err != nil {
 		status, code, msg, detail := classifyError(err)
 		writeErrorWithCode(w, status, code, msg, detail)
@@ -37,12 +45,21 @@ func (s *Server) handleRunFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !(clean == "screenshots" || strings.HasPrefix(clean, "screenshots"+string(filepath.Separator))) {
-		writeError(w, http.StatusBadRequest, "only screenshots path allowed")
+	if !isRunImagePath(clean) {
+		writeError(w, http.StatusBadRequest, "only screenshots and images allowed")
+		return
+	}
+	if !s.runFileReferenced(r.Context(), runID, filepath.ToSlash(clean)) {
+		writeError(w, http.StatusNotFound, "file not referenced by the run")
+		return
+	}
+
+	if src.Member != "" {
+		s.serveArchiveFile(w, r, src, filepath.ToSlash(clean))
 		return
 	}
 
-	baseDir := filepath.Dir(runFile)
+	baseDir := filepath.Dir(src.Path)
 	abs := filepath.Join(baseDir, clean)
 	absClean, err := filepath.Abs(abs)
 	if err != nil {
@@ -68,3 +85,109 @@ func (s *Server) handleRunFile(w http.ResponseWriter, r *http.Request) {
 
 	http.ServeFile(w, r, absClean)
 }
+
+// runImageExts are the image files served from anywhere next to a run, such
+// as SeleniumLibrary's selenium-screenshot-1.png or Browser's
+// browser/screenshot/*.png. SVG is left out because it can carry scripts.
+var runImageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true,
+}
+
+// isRunImagePath reports whether a cleaned relative path may be served by
+// /api/run-file: anything under screenshots/, or an image file. The path
+// must also be referenced by the run, see runFileReferenced.
+func isRunImagePath(clean string) bool {
+	if clean == "screenshots" || strings.HasPrefix(clean, "screenshots"+string(filepath.Separator)) {
+		return true
+	}
+	return runImageExts[strings.ToLower(filepath.Ext(clean))]
+}
+
+// maxRunFileRefs bounds the paths kept by runFileRefs; past it the set
+// starts over and is filled again as messages are sent.
+const maxRunFileRefs = 1 << 16
+
+// runFileRefs holds the relative paths that HTML messages sent to the UI
+// point to, keyed by run. /api/run-file serves only these, so a run's
+// directory is not readable beyond the screenshots its log shows.
+type runFileRefs struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+func newRunFileRefs() *runFileRefs {
+	return &runFileRefs{paths: make(map[string]bool)}
+}
+
+// add records rel, a slash-separated path, as referenced by the run.
+func (f *runFileRefs) add(runID, rel string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.paths) >= maxRunFileRefs {
+		f.paths = make(map[string]bool)
+	}
+	f.paths[runID+"\x00"+path.Clean(rel)] = true
+}
+
+func (f *runFileRefs) has(runID, rel string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.paths[runID+"\x00"+path.Clean(rel)]
+}
+
+// runFileReferenced reports whether an HTML message of the run points to
+// rel. Paths are recorded when messages are sent to the UI; one that was
+// dropped or predates a restart is looked up again in the run's loaded
+// messages, like artifacts are.
+func (s *Server) runFileReferenced(ctx context.Context, runID, rel string) bool {
+	if s.runFiles.has(runID, rel) {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	_, _, robots, err := s.store.GetRuns(ctx, []string{runID})
+	if err != nil {
+		return false
+	}
+	mr := s.messageRenderer(runID)
+	forEachRunMessage(robots[0], func(msg rdiff.Message) {
+		if msg.HTML {
+			mr.text(msg)
+		}
+	})
+	return s.runFiles.has(runID, rel)
+}
+
+// maxArchiveFileBytes caps how much of an archive member is buffered to serve
+// it; screenshots are far smaller.
+const maxArchiveFileBytes = 64 << 20
+
+// serveArchiveFile serves rel from next to the run's result inside its
+// archive. The member is buffered so ServeContent can answer range requests.
+func (s *Server) serveArchiveFile(w http.ResponseWriter, r *http.Request, src rdiff.ResultSource, rel string) {
+	rc, err := src.OpenSibling(rel)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxArchiveFileBytes+1))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "read archive file")
+		return
+	}
+	if len(data) > maxArchiveFileBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, "archive file too large")
+		return
+	}
+
+	var modTime time.Time
+	if info, err := os.Stat(src.Path); err == nil {
+		modTime = info.ModTime()
+	}
+	http.ServeContent(w, r, path.Base(rel), modTime, bytes.NewReader(data))
+}
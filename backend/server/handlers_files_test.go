@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"robot_diff/backend/store"
+)
+
+const screenshotRun = `<?xml version="1.0" encoding="UTF-8"?>
+<robot generator="Robot 7.0" generated="2024-05-02T10:00:00.000000" rpa="false" schemaversion="5">
+<suite id="s1" name="Tests" source="/src/tests.robot">
+<test id="s1-t1" name="Broken Page" line="1">
+<kw name="Capture Page Screenshot" owner="SeleniumLibrary">
+<msg time="2024-05-02T10:00:00.100000" level="INFO" html="true">&lt;a href="shots/page.png"&gt;&lt;img src="shots/page.png"&gt;&lt;/a&gt;</msg>
+<status status="PASS" start="2024-05-02T10:00:00.000000" elapsed="0.1"/>
+</kw>
+<status status="FAIL" start="2024-05-02T10:00:00.000000" elapsed="0.2">broken</status>
+</test>
+<status status="FAIL" start="2024-05-02T10:00:00.000000" elapsed="0.2"/>
+</suite>
+<statistics>
+<total><stat pass="0" fail="1" skip="0">All Tests</stat></total>
+<tag></tag>
+<suite><stat pass="0" fail="1" skip="0" id="s1" name="Tests">Tests</stat></suite>
+</statistics>
+<errors></errors>
+</robot>
+`
+
+func TestRunFileServesReferencedImages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"output.xml":      screenshotRun,
+		"shots/page.png":  "page",
+		"shots/other.png": "other",
+		"photos/cat.jpg":  "cat",
+	}
+	old := time.Now().Add(-time.Hour)
+	for rel, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	runs := store.NewRunStore(dir, time.Hour, store.Options{})
+	runs.ScanOnce()
+	list := runs.ListRuns()
+	if len(list) != 1 {
+		t.Fatalf("got %d runs, want 1", len(list))
+	}
+	s := NewServer("", runs)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"shots/page.png", http.StatusOK, "page"},
+		{"shots/./page.png", http.StatusOK, "page"},
+		{"shots/other.png", http.StatusNotFound, ""},
+		{"photos/cat.jpg", http.StatusNotFound, ""},
+		{"output.xml", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		target := "/api/run-file?runId=" + url.QueryEscape(list[0].ID) + "&path=" + url.QueryEscape(tt.path)
+		rec := httptest.NewRecorder()
+		s.handleRunFile(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.path, rec.Code, tt.status)
+			continue
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: body %q, want %q", tt.path, rec.Body.String(), tt.body)
+		}
+	}
+}
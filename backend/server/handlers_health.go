@@ -16,8 +16,26 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	cfg := s.store.Config()
-	writeJSON(w, http.StatusOK, map[string]any{
-		"dir":          cfg.Dir,
-		"scanInterval": cfg.Interval.String(),
-	})
+	payload := map[string]any{
+		"dir":               cfg.Dir,
+		"scanInterval":      cfg.Interval.String(),
+		"maxKeywordDepth":   cfg.Parse.MaxKeywordDepth,
+		"dropPassingBodies": cfg.Parse.DropPassingBodies,
+		"indexMode":         cfg.Parse.IndexOnly,
+		"watchMode":         cfg.WatchMode,
+		"memory":            s.store.MemoryUsage(),
+	}
+	if len(cfg.MergePatterns) > 0 {
+		payload["mergePatterns"] = cfg.MergePatterns
+	}
+	if len(cfg.LabelMetadata) > 0 {
+		payload["labelMetadata"] = cfg.LabelMetadata
+	}
+	if cfg.LabelPattern != "" {
+		payload["labelPattern"] = cfg.LabelPattern
+	}
+	if cfg.ReconcileInterval > 0 {
+		payload["reconcileInterval"] = cfg.ReconcileInterval.String()
+	}
+	writeJSON(w, http.StatusOK, payload)
 }
\ No newline at end of file
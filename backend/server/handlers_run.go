@@ -4,11 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
+
+	rdiff "robot_diff/backend/diff"
 )
 
 type runRequest struct {
 	RunID string `json:"runId"`
+	// IncludeTags and ExcludeTags are tag patterns as robot's --include and
+	// --exclude take them.
+	IncludeTags []string `json:"includeTags"`
+	ExcludeTags []string `json:"excludeTags"`
 }
 
 type testDetailsRequest struct {
@@ -42,14 +49,17 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	robot := robots[0]
+	robot := rdiff.NewTagFilter(req.IncludeTags, req.ExcludeTags).Apply(robots[0])
 	timeBreakdown, timeSummary := buildTimeBreakdownData(&robot.Suite)
+	mr := s.messageRenderer(req.RunID)
 	data := map[string]any{
 		"title":         columns[0],
 		"file":          inputFiles[0],
-		"suites":        buildSuitesData(&robot.Suite),
+		"suites":        buildSuitesData(&robot.Suite, mr),
 		"timeBreakdown": timeBreakdown,
 		"timeSummary":   timeSummary,
+		"errors":        buildMessagesData(robot.Errors, mr),
+		"statistics":    buildStatisticsData(robot),
 	}
 	writeJSON(w, http.StatusOK, data)
 }
@@ -81,12 +91,22 @@ func (s *Server) handleTestDetails(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := map[string]any{
-		"runId":  req.RunID,
+		"runId":    req.RunID,
 		"name":     test.Name,
 		"status":   test.Status.Status,
+		"message":  strings.TrimSpace(test.Status.Message),
 		"start":    test.Status.StartTime,
 		"end":      test.Status.EndTime,
-		"keywords": buildKeywordsData(buildTestBodyKeywords(test)),
+		"tags":     nonNilStrings(test.Tags),
+		"doc":      test.Doc,
+		"timeout":  test.Timeout,
+		"keywords": buildKeywordsData(buildTestBodyKeywords(test), s.messageRenderer(req.RunID)),
+	}
+	if test.FirstAttempt != nil {
+		data["firstAttempt"] = map[string]any{
+			"status":  test.FirstAttempt.Status,
+			"message": strings.TrimSpace(test.FirstAttempt.Message),
+		}
 	}
 	writeJSON(w, http.StatusOK, data)
 }
@@ -3,6 +3,9 @@ package backend
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"robot_diff/backend/store"
 )
 
 type deleteRunsRequest struct {
@@ -80,10 +83,61 @@ func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	query := r.URL.Query()
+	want := make(map[string]string)
+	for _, filter := range query["label"] {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			writeError(w, http.StatusBadRequest, "label filters must look like key=value")
+			return
+		}
+		want[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
 	cfg := s.store.Config()
 	runs := s.store.ListRuns()
-	writeJSON(w, http.StatusOK, map[string]any{
+	if len(want) > 0 {
+		filtered := runs[:0]
+		for _, run := range runs {
+			if store.MatchLabels(run.Labels, want) {
+				filtered = append(filtered, run)
+			}
+		}
+		runs = filtered
+	}
+	data := map[string]any{
 		"dir":  cfg.Dir,
 		"runs": runs,
-	})
+	}
+	if groupBy := strings.TrimSpace(query.Get("groupBy")); groupBy != "" {
+		data["groups"] = groupRunsByLabel(runs, groupBy)
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+type runGroup struct {
+	Label string `json:"label"`
+	// Value is "" for runs without the label.
+	Value  string   `json:"value"`
+	RunIDs []string `json:"runIds"`
+	// LatestID is the most recently modified run of the group.
+	LatestID string `json:"latestId"`
+}
+
+// groupRunsByLabel groups runs, which ListRuns orders newest first, by the
+// value of one label. Groups are ordered by their latest run.
+func groupRunsByLabel(runs []store.RunInfo, label string) []runGroup {
+	groups := make([]runGroup, 0)
+	index := make(map[string]int)
+	for _, run := range runs {
+		value := run.Labels[label]
+		i, ok := index[value]
+		if !ok {
+			i = len(groups)
+			index[value] = i
+			groups = append(groups, runGroup{Label: label, Value: value, RunIDs: []string{}, LatestID: run.ID})
+		}
+		groups[i].RunIDs = append(groups[i].RunIDs, run.ID)
+	}
+	return groups
 }
@@ -0,0 +1,290 @@
+package backend
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	rdiff "robot_diff/backend/diff"
+)
+
+// HTML messages (html="true" in output.xml, typically screenshots logged by
+// SeleniumLibrary or Browser) are shown as markup in the UI, so they are
+// cleaned up here first: only formatting elements and harmless attributes
+// survive, links and images are limited to safe URLs, and images are
+// rewritten to URLs served by this server.
+
+// allowedHTMLElements are kept; other elements are dropped but their text is
+// kept, except for droppedHTMLElements whose content goes too.
+var allowedHTMLElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "blockquote": true, "br": true,
+	"code": true, "dd": true, "div": true, "dl": true, "dt": true, "em": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"hr": true, "i": true, "img": true, "li": true, "ol": true, "p": true,
+	"pre": true, "s": true, "small": true, "span": true, "strong": true,
+	"sub": true, "sup": true, "table": true, "tbody": true, "td": true,
+	"tfoot": true, "th": true, "thead": true, "tr": true, "u": true, "ul": true,
+}
+
+var droppedHTMLElements = map[string]bool{
+	"embed": true, "frame": true, "frameset": true, "iframe": true,
+	"math": true, "noscript": true, "object": true, "script": true,
+	"style": true, "svg": true, "template": true, "textarea": true,
+}
+
+var voidHTMLElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+var allowedHTMLAttrs = map[string]bool{
+	"align": true, "alt": true, "class": true, "colspan": true,
+	"height": true, "rowspan": true, "title": true, "width": true,
+}
+
+// sanitizeMessageHTML returns the allowed part of an HTML message. Link and
+// image URLs go through rewriteURL, which returns "" to drop the attribute.
+// Robot's HTML messages are fragments that often start inside a table
+// (SeleniumLibrary logs "</td></tr><tr><td ...><img ...>"), so stray end tags
+// are ignored and elements left open are closed at the end.
+func sanitizeMessageHTML(text string, rewriteURL func(attr, value string) string) string {
+	var b strings.Builder
+	var open []string
+	for len(text) > 0 {
+		lt := strings.IndexByte(text, '<')
+		if lt < 0 {
+			lt = len(text)
+		}
+		b.WriteString(html.EscapeString(html.UnescapeString(text[:lt])))
+		text = text[lt:]
+		if text == "" {
+			break
+		}
+		tag, rest, ok := readHTMLTag(text)
+		if !ok {
+			b.WriteString("&lt;")
+			text = text[1:]
+			continue
+		}
+		text = rest
+		switch {
+		case tag.name == "":
+			// Comment, doctype or processing instruction.
+		case droppedHTMLElements[tag.name]:
+			if !tag.end && !tag.selfClosing {
+				text = skipHTMLElement(text, tag.name)
+			}
+		case !allowedHTMLElements[tag.name]:
+		case tag.end:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tag.name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		default:
+			b.WriteString("<" + tag.name)
+			for _, a := range tag.attrs {
+				value := a.value
+				switch {
+				case (tag.name == "a" && a.name == "href") || (tag.name == "img" && a.name == "src"):
+					if value = rewriteURL(a.name, value); value == "" {
+						continue
+					}
+				case !allowedHTMLAttrs[a.name]:
+					continue
+				}
+				b.WriteString(" " + a.name + `="` + html.EscapeString(value) + `"`)
+			}
+			if tag.name == "a" {
+				b.WriteString(` target="_blank" rel="noopener noreferrer"`)
+			}
+			b.WriteString(">")
+			if !voidHTMLElements[tag.name] && !tag.selfClosing {
+				open = append(open, tag.name)
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+type htmlTag struct {
+	name        string
+	end         bool
+	selfClosing bool
+	attrs       []htmlAttr
+}
+
+type htmlAttr struct {
+	name, value string
+}
+
+// readHTMLTag parses the tag at the start of s ("<" included) and returns
+// the text after it. Comments and declarations come back with an empty name.
+// ok is false when s does not start a tag, in which case "<" is text.
+func readHTMLTag(s string) (tag htmlTag, rest string, ok bool) {
+	if strings.HasPrefix(s, "<!--") {
+		if end := strings.Index(s[4:], "-->"); end >= 0 {
+			return htmlTag{}, s[4+end+3:], true
+		}
+		return htmlTag{}, "", true
+	}
+	if strings.HasPrefix(s, "<!") || strings.HasPrefix(s, "<?") {
+		if end := strings.IndexByte(s, '>'); end >= 0 {
+			return htmlTag{}, s[end+1:], true
+		}
+		return htmlTag{}, "", true
+	}
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		tag.end = true
+		i++
+	}
+	start := i
+	for i < len(s) && isHTMLNameByte(s[i]) {
+		i++
+	}
+	if i == start || !isHTMLLetter(s[start]) {
+		return htmlTag{}, s, false
+	}
+	tag.name = strings.ToLower(s[start:i])
+	for {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			return tag, "", true
+		}
+		switch s[i] {
+		case '>':
+			return tag, s[i+1:], true
+		case '/':
+			tag.selfClosing = true
+			i++
+			continue
+		}
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		attr := htmlAttr{name: strings.ToLower(s[start:i])}
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					return tag, "", true
+				}
+				attr.value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				attr.value = s[start:i]
+			}
+		}
+		if attr.name == "" {
+			continue
+		}
+		attr.value = html.UnescapeString(attr.value)
+		tag.attrs = append(tag.attrs, attr)
+	}
+}
+
+// skipHTMLElement returns the text after the end tag of name, skipping the
+// element's content without interpreting it.
+func skipHTMLElement(s, name string) string {
+	lower := strings.ToLower(s)
+	for off := 0; ; {
+		i := strings.Index(lower[off:], "</"+name)
+		if i < 0 {
+			return ""
+		}
+		off += i + 2 + len(name)
+		if off < len(s) && isHTMLNameByte(s[off]) {
+			continue
+		}
+		if end := strings.IndexByte(s[off:], '>'); end >= 0 {
+			return s[off+end+1:]
+		}
+		return ""
+	}
+}
+
+func isHTMLLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isHTMLNameByte(c byte) bool {
+	return isHTMLLetter(c) || (c >= '0' && c <= '9') || c == '-' || c == ':'
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// messageRenderer prepares the messages of one run for the UI.
+type messageRenderer struct {
+	runID     string
+	artifacts *artifactStore
+	runFiles  *runFileRefs
+}
+
+func (s *Server) messageRenderer(runID string) *messageRenderer {
+	return &messageRenderer{runID: runID, artifacts: s.artifacts, runFiles: s.runFiles}
+}
+
+// text returns the message text to send: plain messages as they are, HTML
+// messages sanitized.
+func (mr *messageRenderer) text(msg rdiff.Message) string {
+	if !msg.HTML {
+		return msg.Text
+	}
+	return sanitizeMessageHTML(msg.Text, mr.rewriteURL)
+}
+
+// rewriteURL allows http(s) links and images, and mailto and fragment links.
+// Embedded data: images become artifacts and relative paths are served from
+// next to the run by /api/run-file, which is told about them. Anything else
+// is dropped.
+func (mr *messageRenderer) rewriteURL(attr, value string) string {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return value
+	case attr == "href" && (strings.HasPrefix(lower, "mailto:") || strings.HasPrefix(value, "#")):
+		return value
+	case strings.HasPrefix(lower, "data:"):
+		if mr == nil || mr.artifacts == nil || attr != "src" {
+			return ""
+		}
+		id, ok := mr.artifacts.putDataURI(mr.runID, value)
+		if !ok {
+			return ""
+		}
+		return artifactURL(mr.runID, id)
+	}
+	if mr == nil || mr.runID == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return ""
+	}
+	mr.runFiles.add(mr.runID, u.Path)
+	return "/api/run-file?runId=" + url.QueryEscape(mr.runID) + "&path=" + url.QueryEscape(u.Path)
+}
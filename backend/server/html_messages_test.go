@@ -0,0 +1,154 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeMessageHTML(t *testing.T) {
+	mr := &messageRenderer{runID: "run1", artifacts: newArtifactStore()}
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "formatting kept",
+			in:   `<b>bold</b> and <i class="x">italic</i>`,
+			want: `<b>bold</b> and <i class="x">italic</i>`,
+		},
+		{
+			name: "javascript href dropped",
+			in:   `<a href="javascript:alert(1)">click</a>`,
+			want: `<a target="_blank" rel="noopener noreferrer">click</a>`,
+		},
+		{
+			name: "entity-encoded javascript href dropped",
+			in:   `<a href="java&#115;cript:alert(1)">click</a>`,
+			want: `<a target="_blank" rel="noopener noreferrer">click</a>`,
+		},
+		{
+			name: "http link kept",
+			in:   `<a href="https://example.com/?a=1&amp;b=2">docs</a>`,
+			want: `<a href="https://example.com/?a=1&amp;b=2" target="_blank" rel="noopener noreferrer">docs</a>`,
+		},
+		{
+			name: "event handlers dropped",
+			in:   `<img src="a.png" onerror="alert(1)" ONload='x()'><p onclick=alert(1)>text</p>`,
+			want: `<img src="/api/run-file?runId=run1&amp;path=a.png"><p>text</p>`,
+		},
+		{
+			name: "script content removed",
+			in:   `before<script>alert("<b>x</b>")</script>after`,
+			want: `beforeafter`,
+		},
+		{
+			name: "style content removed",
+			in:   `<style>body { display: none }</style>text`,
+			want: `text`,
+		},
+		{
+			name: "svg content removed",
+			in:   `<svg><script>alert(1)</script><text>x</text></svg>ok`,
+			want: `ok`,
+		},
+		{
+			name: "iframe content removed",
+			in:   `<IFRAME src="https://evil.example">fallback</IFRAME>ok`,
+			want: `ok`,
+		},
+		{
+			name: "unknown elements keep their text",
+			in:   `<font color="red">red</font>`,
+			want: `red`,
+		},
+		{
+			name: "unquoted attributes",
+			in:   `<td colspan=2 onmouseover=alert(1)>cell</td>`,
+			want: `<td colspan="2">cell</td>`,
+		},
+		{
+			name: "unterminated attribute drops the rest",
+			in:   `<img src="a.png" alt="never closed>text`,
+			want: `<img src="/api/run-file?runId=run1&amp;path=a.png">`,
+		},
+		{
+			name: "unterminated tag",
+			in:   `text<b class="x"`,
+			want: `text<b class="x"></b>`,
+		},
+		{
+			name: "stray end tags of a Selenium screenshot",
+			in:   `</td></tr><tr><td colspan="3"><a href="selenium-screenshot-1.png"><img src="selenium-screenshot-1.png" width="800px"></a>`,
+			want: `<tr><td colspan="3"><a href="/api/run-file?runId=run1&amp;path=selenium-screenshot-1.png" target="_blank" rel="noopener noreferrer">` +
+				`<img src="/api/run-file?runId=run1&amp;path=selenium-screenshot-1.png" width="800px"></a></td></tr>`,
+		},
+		{
+			name: "protocol-relative src dropped",
+			in:   `<img src="//evil.example/x.png">`,
+			want: `<img>`,
+		},
+		{
+			name: "absolute path src dropped",
+			in:   `<img src="/etc/passwd">`,
+			want: `<img>`,
+		},
+		{
+			name: "svg data URI dropped",
+			in:   `<img src="data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=">`,
+			want: `<img>`,
+		},
+		{
+			name: "text escaped",
+			in:   `1 < 2 &amp; 3 > 2 "quoted"`,
+			want: `1 &lt; 2 &amp; 3 &gt; 2 &#34;quoted&#34;`,
+		},
+		{
+			name: "comments removed",
+			in:   `a<!-- <script>alert(1)</script> -->b`,
+			want: `ab`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeMessageHTML(tt.in, mr.rewriteURL); got != tt.want {
+				t.Errorf("sanitizeMessageHTML(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteURL(t *testing.T) {
+	mr := &messageRenderer{runID: "run 1", artifacts: newArtifactStore()}
+	tests := []struct {
+		attr, value, want string
+	}{
+		{"href", "https://example.com/a", "https://example.com/a"},
+		{"src", " HTTP://example.com/a.png ", "HTTP://example.com/a.png"},
+		{"href", "mailto:qa@example.com", "mailto:qa@example.com"},
+		{"href", "#section", "#section"},
+		{"src", "#section", ""},
+		{"href", "javascript:alert(1)", ""},
+		{"href", " JavaScript:alert(1)", ""},
+		{"src", "vbscript:msgbox", ""},
+		{"src", "//evil.example/x.png", ""},
+		{"src", "/abs/x.png", ""},
+		{"src", "file:///etc/passwd", ""},
+		{"src", "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=", ""},
+		{"src", "data:text/html;base64,PGI+eDwvYj4=", ""},
+		{"href", "data:image/png;base64,iVBORw0KGgo=", ""},
+		{"src", "shots/screen 1.png", "/api/run-file?runId=run+1&path=shots%2Fscreen+1.png"},
+		{"href", "../report.html", "/api/run-file?runId=run+1&path=..%2Freport.html"},
+	}
+	for _, tt := range tests {
+		if got := mr.rewriteURL(tt.attr, tt.value); got != tt.want {
+			t.Errorf("rewriteURL(%q, %q) = %q, want %q", tt.attr, tt.value, got, tt.want)
+		}
+	}
+
+	got := mr.rewriteURL("src", "data:image/png;base64,iVBORw0KGgo=")
+	if !strings.HasPrefix(got, "/api/artifact?runId=run+1&id=") {
+		t.Errorf("png data URI rewritten to %q, want an artifact URL", got)
+	}
+	if got := (*messageRenderer)(nil).rewriteURL("src", "shots/a.png"); got != "" {
+		t.Errorf("relative path without a run rewritten to %q", got)
+	}
+}
@@ -8,12 +8,14 @@ import (
 )
 
 type Server struct {
-	store *store.RunStore
-	addr  string
+	store     *store.RunStore
+	addr      string
+	artifacts *artifactStore
+	runFiles  *runFileRefs
 }
 
 func NewServer(addr string, store *store.RunStore) *Server {
-	return &Server{addr: addr, store: store}
+	return &Server{addr: addr, store: store, artifacts: newArtifactStore(), runFiles: newRunFileRefs()}
 }
 
 func (s *Server) ListenAndServe() error {
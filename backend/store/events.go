@@ -0,0 +1,91 @@
+package store
+
+import "sync"
+
+// Event types published by the run store.
+const (
+	EventRunAdded          = "run-added"
+	EventRunRemoved        = "run-removed"
+	EventRunUpdated        = "run-updated"
+	EventHydrationFinished = "hydration-finished"
+)
+
+// Event describes a change to the run list. Run is nil for removals and for
+// hydration-finished, which carries the number of hydrated runs in Count.
+type Event struct {
+	Type  string   `json:"type"`
+	ID    string   `json:"id,omitempty"`
+	Run   *RunInfo `json:"run,omitempty"`
+	Count int      `json:"count,omitempty"`
+}
+
+const subscriberBuffer = 64
+
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// Subscribe returns a channel of store events and a function that stops the
+// subscription. Slow subscribers miss events rather than blocking the scanner,
+// so consumers should treat an event as a hint to re-read /api/runs.
+func (s *RunStore) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	s.events.mu.Lock()
+	if s.events.subs == nil {
+		s.events.subs = make(map[chan Event]struct{})
+	}
+	s.events.subs[ch] = struct{}{}
+	s.events.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.events.mu.Lock()
+			delete(s.events.subs, ch)
+			s.events.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (s *RunStore) publish(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	for ch := range s.events.subs {
+		for _, ev := range events {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
+
+// diffRuns turns two scan results into added/removed/updated events. Entries
+// that were carried over unchanged keep their pointer, so pointer equality is
+// enough to detect updates.
+func diffRuns(prev, updated map[string]*runEntry) []Event {
+	var events []Event
+	for id, e := range updated {
+		old, ok := prev[id]
+		if !ok || old == nil {
+			info := e.info
+			events = append(events, Event{Type: EventRunAdded, ID: id, Run: &info})
+			continue
+		}
+		if old != e {
+			info := e.info
+			events = append(events, Event{Type: EventRunUpdated, ID: id, Run: &info})
+		}
+	}
+	for id := range prev {
+		if _, ok := updated[id]; !ok {
+			events = append(events, Event{Type: EventRunRemoved, ID: id})
+		}
+	}
+	return events
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	robodiff "robot_diff/backend/diff"
+)
+
+// Run labels are key/value pairs such as branch=main or env=staging attached
+// to RunInfo. They come from named groups of Options.LabelPattern matched
+// against the run's relative path and from the top-level suite metadata listed
+// in Options.LabelMetadata; metadata wins when both set a label.
+
+// ParseLabelMetadata turns "label=Metadata Name" entries into the map used by
+// Options.LabelMetadata. An entry without "=" uses the metadata name, lower
+// cased and with spaces replaced by underscores, as the label.
+func ParseLabelMetadata(entries []string) map[string]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		label, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			name = entry
+			label = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(entry)), " ", "_")
+		}
+		label, name = strings.TrimSpace(label), strings.TrimSpace(name)
+		if label != "" && name != "" {
+			out[label] = name
+		}
+	}
+	return out
+}
+
+// wantsMetadataLabels reports whether labels need the suite metadata, which
+// is only available after reading the whole result.
+func (s *RunStore) wantsMetadataLabels() bool {
+	return len(s.opts.LabelMetadata) > 0
+}
+
+// pathLabels returns the labels taken from relPath by Options.LabelPattern.
+func (s *RunStore) pathLabels(relPath string) map[string]string {
+	re := s.opts.LabelPattern
+	if re == nil {
+		return nil
+	}
+	match := re.FindStringSubmatch(relPath)
+	if match == nil {
+		return nil
+	}
+	var labels map[string]string
+	for i, name := range re.SubexpNames() {
+		if name == "" || match[i] == "" {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[name] = match[i]
+	}
+	return labels
+}
+
+// runLabels combines the path labels of relPath with the labels found in the
+// top-level suite metadata. Metadata names are matched case-insensitively.
+func (s *RunStore) runLabels(relPath string, metadata []robodiff.Metadata) map[string]string {
+	labels := s.pathLabels(relPath)
+	for label, name := range s.opts.LabelMetadata {
+		for _, meta := range metadata {
+			if !strings.EqualFold(strings.TrimSpace(meta.Name), name) {
+				continue
+			}
+			if value := strings.TrimSpace(meta.Value); value != "" {
+				if labels == nil {
+					labels = make(map[string]string)
+				}
+				labels[label] = value
+			}
+			break
+		}
+	}
+	return labels
+}
+
+// labelConfigKey describes the label configuration so cached labels are
+// recomputed when it changes between runs of the server.
+func (s *RunStore) labelConfigKey() string {
+	var parts []string
+	if s.opts.LabelPattern != nil {
+		parts = append(parts, "pattern="+s.opts.LabelPattern.String())
+	}
+	keys := make([]string, 0, len(s.opts.LabelMetadata))
+	for label, name := range s.opts.LabelMetadata {
+		keys = append(keys, label+"="+name)
+	}
+	sort.Strings(keys)
+	return strings.Join(append(parts, keys...), "\n")
+}
+
+// MatchLabels reports whether labels hold every key=value in want. Values are
+// compared case-insensitively and may use * wildcards.
+func MatchLabels(labels, want map[string]string) bool {
+	for key, pattern := range want {
+		value, ok := labels[key]
+		if !ok || !labelValueMatches(pattern, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func labelValueMatches(pattern, value string) bool {
+	if !strings.Contains(pattern, "*") {
+		return strings.EqualFold(pattern, value)
+	}
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	re, err := regexp.Compile("(?i)^" + quoted + "$")
+	return err == nil && re.MatchString(value)
+}
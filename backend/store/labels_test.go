@@ -0,0 +1,48 @@
+package store
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPathLabels(t *testing.T) {
+	for _, relative := range []bool{false, true} {
+		name := "absolute dir"
+		if relative {
+			name = "relative dir"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeResult(t, dir, "nightly/2024-05-02/output.xml", time.Hour)
+			writeResult(t, dir, "pr/123/output.xml", time.Hour)
+			writeResult(t, dir, "output.xml", time.Hour)
+			storeDir := dir
+			if relative {
+				chdir(t, dir)
+				storeDir = "."
+			}
+			s := newTestStore(t, storeDir, Options{
+				LabelPattern: regexp.MustCompile(`^(?P<branch>[^/]+)/(?P<build>[^/]+)/`),
+			})
+			s.ScanOnce()
+
+			want := map[string]map[string]string{
+				"nightly/2024-05-02/output.xml": {"branch": "nightly", "build": "2024-05-02"},
+				"pr/123/output.xml":             {"branch": "pr", "build": "123"},
+				"output.xml":                    nil,
+			}
+			wantPaths := []string{"nightly/2024-05-02/output.xml", "output.xml", "pr/123/output.xml"}
+			if got := relPaths(s); !slices.Equal(got, wantPaths) {
+				t.Fatalf("runs %v, want %v", got, wantPaths)
+			}
+			for _, info := range s.ListRuns() {
+				if labels, ok := want[info.RelPath]; !ok || !maps.Equal(info.Labels, labels) {
+					t.Errorf("%s: labels %v, want %v", info.RelPath, info.Labels, labels)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	robodiff "robot_diff/backend/diff"
+)
+
+// Logical runs: a directory holding several outputs of one execution (pabot
+// workers, a run and its --rerunfailed rerun) that is listed as a single run
+// whose results are merged like `rebot --merge`.
+
+// MergeMarkerName is the file that turns its directory into one logical run.
+// It may list glob patterns, one per line and relative to the directory, of
+// the outputs to merge; an empty marker uses DefaultMergePatterns.
+const MergeMarkerName = ".robodiff-merge"
+
+// DefaultMergePatterns select the outputs of a marked directory: the usual
+// output/rerun files and pabot's per-worker results.
+var DefaultMergePatterns = []string{
+	"output*.xml",
+	"output*.json",
+	"rerun*.xml",
+	"pabot_results/*/output.xml",
+	"pabot_results/*/output.json",
+}
+
+// logicalRunParts returns the outputs to merge when absDir is a logical run:
+// it holds a merge marker, or Options.MergePatterns match at least two
+// results in it. Parts are ordered oldest first so reruns replace the
+// original results.
+func (s *RunStore) logicalRunParts(absDir string) []robodiff.ResultSource {
+	patterns := s.opts.MergePatterns
+	minParts := 2
+	if data, err := os.ReadFile(filepath.Join(absDir, MergeMarkerName)); err == nil {
+		minParts = 1
+		patterns = DefaultMergePatterns
+		if listed := parsePatternLines(data); len(listed) > 0 {
+			patterns = listed
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	type part struct {
+		src     robodiff.ResultSource
+		modTime time.Time
+	}
+	var parts []part
+	seen := make(map[string]struct{})
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(absDir, filepath.FromSlash(pattern)))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			if _, ok := seen[match]; ok || !robodiff.IsResultFileName(match) {
+				continue
+			}
+			seen[match] = struct{}{}
+			fi, err := os.Stat(match)
+			if err != nil || fi.IsDir() {
+				continue
+			}
+			format := robodiff.DetectFileFormat(match)
+			if format == "" {
+				continue
+			}
+			parts = append(parts, part{src: robodiff.ResultSource{Path: match, Format: format}, modTime: fi.ModTime()})
+		}
+	}
+	if len(parts) < minParts {
+		return nil
+	}
+
+	sort.SliceStable(parts, func(i, j int) bool {
+		if !parts[i].modTime.Equal(parts[j].modTime) {
+			return parts[i].modTime.Before(parts[j].modTime)
+		}
+		return parts[i].src.Path < parts[j].src.Path
+	})
+	sources := make([]robodiff.ResultSource, len(parts))
+	for i, p := range parts {
+		sources[i] = p.src
+	}
+	return sources
+}
+
+func parsePatternLines(data []byte) []string {
+	var patterns []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// scanLogicalRun records the logical run in absDir. Its parts are marked as
+// consumed so scanFile does not list them again on their own.
+func (s *RunStore) scanLogicalRun(st *scanState, absDir string, parts []robodiff.ResultSource) {
+	if st.consumed == nil {
+		st.consumed = make(map[string]struct{})
+	}
+	for _, part := range parts {
+		st.consumed[part.Path] = struct{}{}
+	}
+
+	modTime, size, err := partsStat(parts)
+	if err != nil {
+		return
+	}
+	abs, err := filepath.Abs(absDir)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(s.dir, abs)
+	if err != nil {
+		rel = filepath.Base(abs)
+	}
+	id := runID(robodiff.ResultSource{Path: abs})
+
+	if existing, ok := st.prev[id]; ok && existing != nil &&
+		existing.info.ModTime.Equal(modTime) && existing.info.Size == size && sameSources(existing.parts, parts) {
+		st.updated[id] = existing
+		return
+	}
+	st.changed = true
+
+	entry := &runEntry{
+		abs:   abs,
+		parts: parts,
+		info: RunInfo{
+			ID:      id,
+			Name:    filepath.Base(abs),
+			RelPath: filepath.ToSlash(rel),
+			ModTime: modTime,
+			Size:    size,
+			Format:  parts[0].Format,
+			Parts:   len(parts),
+			Labels:  s.pathLabels(filepath.ToSlash(rel)),
+		},
+		// Counts of merged outputs need a parse; hydration fills them in.
+		statsIncomplete:    true,
+		durationIncomplete: true,
+		labelsIncomplete:   s.wantsMetadataLabels(),
+		errorsIncomplete:   true,
+	}
+	if st.now.Sub(modTime) < hotFileCooldown {
+		entry.hotUntil = st.now.Add(hotFileCooldown)
+		st.hot = true
+	}
+	st.updated[id] = entry
+}
+
+// partsStat returns the newest modification time and the total size of parts.
+func partsStat(parts []robodiff.ResultSource) (modTime time.Time, size int64, err error) {
+	for _, part := range parts {
+		fi, err := os.Stat(part.Path)
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+		size += fi.Size()
+	}
+	return modTime, size, nil
+}
+
+func sameSources(a, b []robodiff.ResultSource) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseMergedRun parses every part and merges them in order.
+func parseMergedRun(ctx context.Context, parts []robodiff.ResultSource, opts robodiff.ParseOptions) (*robodiff.Robot, error) {
+	robots := make([]*robodiff.Robot, len(parts))
+	for i, part := range parts {
+		robot, err := robodiff.ParseResultSourceWithOptions(ctx, part, opts)
+		if err != nil {
+			return nil, err
+		}
+		robots[i] = robot
+	}
+	return robodiff.MergeRobots(robots...), nil
+}
+
+// readMergedSummary returns the summary of a logical run from an index-only
+// parse of its parts; the metadata is that of the merged root suite.
+func readMergedSummary(parts []robodiff.ResultSource) (runSummary, error) {
+	robot, err := parseMergedRun(context.Background(), parts, robodiff.ParseOptions{IndexOnly: true})
+	if err != nil {
+		return runSummary{}, err
+	}
+	summary := runSummary{okStats: true}
+	summary.pass, summary.fail, summary.skip, summary.total = robodiff.CountTests(&robot.Suite)
+	summary.start, summary.end, summary.okTimes = statusTimes(robot.Suite.Status)
+	summary.metadata = robot.Suite.Metadata
+	summary.errors = robot.Errors
+	return summary, nil
+}
+
+// logicalRunDir returns the logical run directory containing path: a
+// directory of a known logical run or one holding a merge marker, looking at
+// most maxScanDepth levels up.
+func (s *RunStore) logicalRunDir(path string, logical map[string]struct{}) (string, bool) {
+	dir := filepath.Dir(path)
+	if filepath.Base(path) == MergeMarkerName {
+		return dir, true
+	}
+	for i := 0; i <= maxScanDepth; i++ {
+		if _, ok := s.dirDepth(dir); !ok {
+			break
+		}
+		if _, ok := logical[dir]; ok {
+			return dir, true
+		}
+		if _, err := os.Stat(filepath.Join(dir, MergeMarkerName)); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", false
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// chdir changes the working directory for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestScanGroupsLogicalRuns(t *testing.T) {
+	for _, relative := range []bool{false, true} {
+		name := "absolute dir"
+		if relative {
+			name = "relative dir"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			// A run and its rerun, grouped by the marker.
+			writeResult(t, dir, "rerun/output.xml", 2*time.Hour)
+			writeResult(t, dir, "rerun/rerun.xml", time.Hour)
+			if err := os.WriteFile(filepath.Join(dir, "rerun", MergeMarkerName), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			// Pabot workers, grouped by MergePatterns.
+			writeResult(t, dir, "pabot/pabot_results/0/output.xml", time.Hour)
+			writeResult(t, dir, "pabot/pabot_results/1/output.xml", time.Hour)
+			// A single output is not a logical run.
+			writeResult(t, dir, "single/output.xml", time.Hour)
+
+			storeDir := dir
+			if relative {
+				chdir(t, dir)
+				storeDir = "."
+			}
+			s := newTestStore(t, storeDir, Options{MergePatterns: []string{"pabot_results/*/output.xml"}})
+			s.ScanOnce()
+
+			if got, want := relPaths(s), []string{"pabot", "rerun", "single/output.xml"}; !slices.Equal(got, want) {
+				t.Fatalf("runs %v, want %v", got, want)
+			}
+			for _, info := range s.ListRuns() {
+				want := 2
+				if info.RelPath == "single/output.xml" {
+					want = 0
+				}
+				if info.Parts != want {
+					t.Errorf("%s: %d parts, want %d", info.RelPath, info.Parts, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	robodiff "robot_diff/backend/diff"
+)
+
+// readJSONStatistics is readRobotStatisticsFast for JSON results.
+// Statistics are written after the suite, so the tail of the file usually
+// has them.
+func readJSONStatistics(src robodiff.ResultSource) (pass, fail, skip, total int, ok bool, err error) {
+	path := src.Path
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, 0, 0, false, err
+	}
+	if info.Size() <= 0 {
+		return 0, 0, 0, 0, false, nil
+	}
+
+	const maxTailBytes = 4 * 1024 * 1024
+	readSize := int64(maxTailBytes)
+	if info.Size() < readSize {
+		readSize = info.Size()
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, 0, 0, false, err
+	}
+	defer f.Close()
+	buf := make([]byte, readSize)
+	_, _ = f.ReadAt(buf, info.Size()-readSize)
+
+	key := []byte(`"statistics"`)
+	if idx := bytes.LastIndex(buf, key); idx != -1 {
+		rest := bytes.TrimLeft(buf[idx+len(key):], " \t\r\n")
+		if stats, err := robodiff.DecodeRobotJSONStatistics(bytes.NewReader(bytes.TrimPrefix(rest, []byte(":")))); err == nil {
+			if pass, fail, skip, ok := stats.Total.AllTests(); ok {
+				return pass, fail, skip, pass + fail + skip, true, nil
+			}
+		}
+	}
+	return 0, 0, 0, 0, false, nil
+}
+
+// readJSONSummary is readRunSummary for JSON results. They have no
+// per-message times worth scanning for; the root suite's start time and
+// elapsed time are exact.
+func readJSONSummary(src robodiff.ResultSource) (runSummary, error) {
+	f, err := src.Open()
+	if err != nil {
+		return runSummary{}, err
+	}
+	defer f.Close()
+	scanned, err := robodiff.ScanRobotJSONSummary(context.Background(), f)
+	if err != nil {
+		return runSummary{}, err
+	}
+	summary := runSummary{metadata: scanned.Metadata, errors: scanned.Errors}
+	if scanned.Statistics != nil {
+		if pass, fail, skip, ok := scanned.Statistics.Total.AllTests(); ok {
+			summary.pass, summary.fail, summary.skip = pass, fail, skip
+			summary.total = pass + fail + skip
+			summary.okStats = true
+		}
+	}
+	summary.start, summary.end, summary.okTimes = statusTimes(scanned.Status)
+	return summary, nil
+}
+
+// statusTimes returns the start and end of a status, deriving the end from
+// the elapsed seconds when the end time is not recorded (RF 7).
+func statusTimes(status robodiff.Status) (start, end time.Time, ok bool) {
+	start, okStart := robodiff.ParseRobotTime(status.StartTime)
+	if !okStart || start.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	if end, okEnd := robodiff.ParseRobotTime(status.EndTime); okEnd && !end.IsZero() {
+		return start, end, true
+	}
+	elapsed, err := strconv.ParseFloat(strings.TrimSpace(status.Elapsed), 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(time.Duration(elapsed * float64(time.Second))), true
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	robodiff "robot_diff/backend/diff"
+)
+
+// JUnit files carry no Robot-style statistics and are usually small, so the
+// counts and duration come from parsing the whole file.
+
+func readJUnitStatistics(src robodiff.ResultSource) (pass, fail, skip, total int, ok bool, err error) {
+	robot, err := parseJUnitSource(src)
+	if err != nil {
+		return 0, 0, 0, 0, false, err
+	}
+	pass, fail, skip, total = robodiff.CountTests(&robot.Suite)
+	return pass, fail, skip, total, true, nil
+}
+
+// readJUnitSummary is readRunSummary for JUnit files: the counts, and the
+// root suite's timestamp and that plus the summed test times. Files without
+// a timestamp are taken to end at their modification time, as that is when
+// the run wrote them.
+func readJUnitSummary(src robodiff.ResultSource) (runSummary, error) {
+	robot, err := parseJUnitSource(src)
+	if err != nil {
+		return runSummary{}, err
+	}
+	summary := runSummary{okStats: true}
+	summary.pass, summary.fail, summary.skip, summary.total = robodiff.CountTests(&robot.Suite)
+	status := robot.Suite.Status
+	elapsed, err := strconv.ParseFloat(strings.TrimSpace(status.Elapsed), 64)
+	if err != nil {
+		return summary, nil
+	}
+	duration := time.Duration(elapsed * float64(time.Second))
+	if start, ok := robodiff.ParseRobotTime(status.StartTime); ok {
+		summary.start, summary.end = start, start.Add(duration)
+	} else {
+		fi, err := os.Stat(src.Path)
+		if err != nil {
+			return summary, nil
+		}
+		summary.start, summary.end = fi.ModTime().Add(-duration), fi.ModTime()
+	}
+	summary.okTimes = true
+	return summary, nil
+}
+
+func parseJUnitSource(src robodiff.ResultSource) (*robodiff.Robot, error) {
+	f, err := src.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return robodiff.ParseJUnitReaderContext(context.Background(), f)
+}
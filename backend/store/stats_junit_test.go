@@ -0,0 +1,56 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	robodiff "robot_diff/backend/diff"
+)
+
+func TestReadJUnitSummaryTimes(t *testing.T) {
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name       string
+		attrs      string
+		start, end time.Time
+	}{
+		{
+			name:  "timestamp",
+			attrs: ` timestamp="2024-05-02T10:00:00"`,
+			start: time.Date(2024, 5, 2, 10, 0, 0, 0, time.Local),
+			end:   time.Date(2024, 5, 2, 10, 0, 1, 500e6, time.Local),
+		},
+		{
+			name:  "no timestamp ends at the modification time",
+			start: modTime.Add(-1500 * time.Millisecond),
+			end:   modTime,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "junit.xml")
+			data := `<testsuite name="suite"` + tt.attrs + `>
+<testcase classname="Tests.Login" name="Quick" time="0.5"/>
+<testcase classname="Tests.Login" name="Slow" time="1"><failure message="boom"/></testcase>
+</testsuite>`
+			if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+			summary, err := readJUnitSummary(robodiff.ResultSource{Path: path, Format: robodiff.FormatJUnit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.pass != 1 || summary.fail != 1 || summary.total != 2 {
+				t.Errorf("counts %d/%d of %d, want 1/1 of 2", summary.pass, summary.fail, summary.total)
+			}
+			if !summary.okTimes || !summary.start.Equal(tt.start) || !summary.end.Equal(tt.end) {
+				t.Errorf("times %v to %v (ok %v), want %v to %v", summary.start, summary.end, summary.okTimes, tt.start, tt.end)
+			}
+		})
+	}
+}
@@ -4,19 +4,22 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -27,11 +30,56 @@ var errRunNotFound = errors.New("run not found")
 
 const hotFileCooldown = 5 * time.Second
 
-const runCacheVersion = 1
+const runCacheVersion = 6
 
 type Config struct {
-	Dir      string
-	Interval time.Duration
+	Dir            string
+	Interval       time.Duration
+	Parse          robodiff.ParseOptions
+	MaxLoadedRuns  int
+	MaxParsedBytes int64
+	// WatchMode is "inotify" when file system events drive scanning and
+	// "poll" otherwise.
+	WatchMode         string
+	ReconcileInterval time.Duration
+	MergePatterns     []string
+	LabelMetadata     map[string]string
+	LabelPattern      string
+}
+
+// Options tunes how the store loads runs. The zero value keeps full runs.
+type Options struct {
+	// Parse is passed to the streaming parser when a run is opened.
+	Parse robodiff.ParseOptions
+	// MaxLoadedRuns and MaxParsedBytes bound the parsed runs kept in memory.
+	// The least recently used runs are dropped first and re-parsed on demand.
+	// Zero means unlimited.
+	MaxLoadedRuns  int
+	MaxParsedBytes int64
+	// Watch reacts to file system events (inotify on Linux) instead of
+	// rescanning every Interval, with a full reconcile every
+	// ReconcileInterval. Unsupported platforms fall back to polling.
+	Watch             bool
+	ReconcileInterval time.Duration
+	// MergePatterns are globs, relative to a directory, that select outputs
+	// to merge into one logical run (see MergeMarkerName). A directory where
+	// they match two or more results is listed as a single run.
+	MergePatterns []string
+	// LabelMetadata maps label names to top-level suite metadata names whose
+	// values become RunInfo.Labels, e.g. {"branch": "Branch"}.
+	LabelMetadata map[string]string
+	// LabelPattern is matched against RunInfo.RelPath and each named group
+	// that matches becomes a label.
+	LabelPattern *regexp.Regexp
+}
+
+// MemoryUsage describes the parsed runs currently held by the store.
+type MemoryUsage struct {
+	LoadedRuns     int   `json:"loadedRuns"`
+	ParsedBytes    int64 `json:"parsedBytes"`
+	MaxLoadedRuns  int   `json:"maxLoadedRuns"`
+	MaxParsedBytes int64 `json:"maxParsedBytes"`
+	Evictions      int64 `json:"evictions"`
 }
 
 type RunInfo struct {
@@ -44,52 +92,137 @@ type RunInfo struct {
 	TestCount  int       `json:"testCount"`
 	PassCount  int       `json:"passCount"`
 	FailCount  int       `json:"failCount"`
+	SkipCount  int       `json:"skipCount"`
+	// Format is the detected result format (see robodiff.Format*).
+	Format string `json:"format"`
+	// Archive is set when the result is compressed or stored in an archive
+	// (see robodiff.Archive*). RelPath then names the member after "!/".
+	Archive string `json:"archive,omitempty"`
+	// Parts is the number of outputs merged into a logical run; zero for
+	// runs read from a single file. RelPath is then the run's directory.
+	Parts int `json:"parts,omitempty"`
+	// Labels are derived from the relative path and the top-level suite
+	// metadata as configured in Options.
+	Labels map[string]string `json:"labels,omitempty"`
+	// ErrorCount and WarningCount count the execution errors and warnings
+	// (the "Test Execution Errors" of log.html).
+	ErrorCount   int `json:"errorCount"`
+	WarningCount int `json:"warningCount"`
 }
 
 type runEntry struct {
-	info         RunInfo
-	abs          string
-	robot        *robodiff.Robot
-	robotModTime time.Time
-	robotSize    int64
+	info   RunInfo
+	abs    string
+	member string
+	// parts are the outputs of a logical run, whose abs is its directory.
+	parts              []robodiff.ResultSource
+	robot              *robodiff.Robot
+	robotModTime       time.Time
+	robotSize          int64
+	robotBytes         int64
+	lastUsed           uint64
 	statsIncomplete    bool
 	durationIncomplete bool
-	hotUntil     time.Time
+	// labelsIncomplete is set until the metadata labels have been read.
+	labelsIncomplete bool
+	// errorsIncomplete is set until the execution errors have been counted.
+	errorsIncomplete bool
+	hotUntil         time.Time
+}
+
+// incomplete reports whether background hydration still has to fill in
+// part of the entry's info.
+func (e *runEntry) incomplete() bool {
+	return e.statsIncomplete || e.durationIncomplete || e.labelsIncomplete || e.errorsIncomplete
+}
+
+// source describes where the entry's result is read from. abs is the file on
+// disk, which for archive members is the archive itself.
+func (e *runEntry) source() robodiff.ResultSource {
+	return robodiff.ResultSource{Path: e.abs, Member: e.member, Archive: e.info.Archive, Format: e.info.Format}
+}
+
+// testSource returns the file a test of the entry was read from.
+func (e *runEntry) testSource(test *robodiff.Test) robodiff.ResultSource {
+	if len(e.parts) > 0 && test.Input >= 0 && test.Input < len(e.parts) {
+		return e.parts[test.Input]
+	}
+	return e.source()
+}
+
+// stat returns the modification time and size that decide whether a parsed
+// run is stale.
+func (e *runEntry) stat() (time.Time, int64, error) {
+	if len(e.parts) > 0 {
+		return partsStat(e.parts)
+	}
+	fi, err := os.Stat(e.abs)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return fi.ModTime(), fi.Size(), nil
 }
 
 type RunStore struct {
-	dir      string
-	interval time.Duration
+	// dir is the absolute results directory; the paths scanned and watched
+	// below it are absolute too.
+	dir       string
+	interval  time.Duration
 	cachePath string
+	opts      Options
 
-	mu   sync.RWMutex
-	runs map[string]*runEntry
+	mu        sync.RWMutex
+	runs      map[string]*runEntry
+	useSeq    uint64
+	evictions int64
 
 	fillMu         sync.Mutex
 	fillInProgress bool
+
+	// scanMu serializes full scans and incremental watch updates.
+	scanMu   sync.Mutex
+	watcher  dirWatcher
+	watching atomic.Bool
+	// emptyArchives holds the archives the last scan found no result in, so
+	// they are not decompressed again until they change. Guarded by scanMu.
+	emptyArchives map[string]fileStamp
+
+	events eventHub
 }
 
 type runCacheSnapshot struct {
-	Version int             `json:"version"`
-	Dir     string          `json:"dir"`
+	Version int    `json:"version"`
+	Dir     string `json:"dir"`
+	// Labels is the label configuration the cached labels were made with.
+	Labels  string          `json:"labels,omitempty"`
 	SavedAt time.Time       `json:"savedAt"`
 	Entries []runCacheEntry `json:"entries"`
 }
 
 type runCacheEntry struct {
-	ID                 string    `json:"id"`
-	Abs                string    `json:"abs"`
-	Info               RunInfo   `json:"info"`
-	RobotModTime       time.Time `json:"robotModTime"`
-	RobotSize          int64     `json:"robotSize"`
-	StatsIncomplete    bool      `json:"statsIncomplete"`
-	DurationIncomplete bool      `json:"durationIncomplete"`
+	ID                 string                  `json:"id"`
+	Abs                string                  `json:"abs"`
+	Member             string                  `json:"member,omitempty"`
+	Parts              []robodiff.ResultSource `json:"parts,omitempty"`
+	Info               RunInfo                 `json:"info"`
+	RobotModTime       time.Time               `json:"robotModTime"`
+	RobotSize          int64                   `json:"robotSize"`
+	StatsIncomplete    bool                    `json:"statsIncomplete"`
+	DurationIncomplete bool                    `json:"durationIncomplete"`
+	LabelsIncomplete   bool                    `json:"labelsIncomplete,omitempty"`
+	ErrorsIncomplete   bool                    `json:"errorsIncomplete,omitempty"`
 }
 
-func NewRunStore(dir string, interval time.Duration) *RunStore {
+func NewRunStore(dir string, interval time.Duration, opts Options) *RunStore {
+	// Scanned paths are absolute, so the root has to be too for them to be
+	// found below it (a relative dir such as the default ".").
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
 	rs := &RunStore{
 		dir:      dir,
 		interval: interval,
+		opts:     opts,
 		runs:     make(map[string]*runEntry, 128),
 	}
 	if cachePath, err := cachePathForDir(dir); err == nil {
@@ -100,13 +233,37 @@ func NewRunStore(dir string, interval time.Duration) *RunStore {
 }
 
 func (s *RunStore) Config() Config {
-	return Config{Dir: s.dir, Interval: s.interval}
+	cfg := Config{
+		Dir:            s.dir,
+		Interval:       s.interval,
+		Parse:          s.opts.Parse,
+		MaxLoadedRuns:  s.opts.MaxLoadedRuns,
+		MaxParsedBytes: s.opts.MaxParsedBytes,
+		WatchMode:      s.WatchMode(),
+		MergePatterns:  s.opts.MergePatterns,
+		LabelMetadata:  s.opts.LabelMetadata,
+	}
+	if s.opts.LabelPattern != nil {
+		cfg.LabelPattern = s.opts.LabelPattern.String()
+	}
+	if cfg.WatchMode == WatchModeInotify {
+		cfg.ReconcileInterval = s.reconcileInterval()
+	}
+	return cfg
 }
 
 func (s *RunStore) Dir() string             { return s.dir }
 func (s *RunStore) Interval() time.Duration { return s.interval }
 
 func (s *RunStore) Start() {
+	if s.opts.Watch {
+		if w, err := newDirWatcher(); err == nil {
+			s.watcher = w
+			s.watching.Store(true)
+			go s.watchLoop()
+			return
+		}
+	}
 	go s.scanLoop()
 }
 
@@ -154,13 +311,81 @@ func (s *RunStore) GetRuns(ctx context.Context, ids []string) (columns []string,
 		if err := s.ensureRobotLoadedLocked(ctx, e); err != nil {
 			return nil, nil, nil, err
 		}
+		s.touchLocked(e)
 		columns = append(columns, e.info.Name)
-		inputFiles = append(inputFiles, e.abs)
+		inputFiles = append(inputFiles, e.source().String())
 		robots = append(robots, e.robot)
 	}
+	s.evictLocked(ids...)
 	return columns, inputFiles, robots, nil
 }
 
+// MemoryUsage reports how many parsed runs are held and their estimated size.
+func (s *RunStore) MemoryUsage() MemoryUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := MemoryUsage{
+		MaxLoadedRuns:  s.opts.MaxLoadedRuns,
+		MaxParsedBytes: s.opts.MaxParsedBytes,
+		Evictions:      s.evictions,
+	}
+	for _, e := range s.runs {
+		if e != nil && e.robot != nil {
+			usage.LoadedRuns++
+			usage.ParsedBytes += e.robotBytes
+		}
+	}
+	return usage
+}
+
+func (s *RunStore) touchLocked(e *runEntry) {
+	s.useSeq++
+	e.lastUsed = s.useSeq
+}
+
+// evictLocked drops least recently used parsed runs until the store is within
+// its budget. Runs listed in keep are never dropped, so a single request can
+// exceed the budget. Evicted entries keep their RunInfo and are re-parsed by
+// ensureRobotLoadedLocked when needed again.
+func (s *RunStore) evictLocked(keep ...string) {
+	if s.opts.MaxLoadedRuns <= 0 && s.opts.MaxParsedBytes <= 0 {
+		return
+	}
+
+	protected := make(map[string]bool, len(keep))
+	for _, id := range keep {
+		protected[id] = true
+	}
+
+	loaded := make([]*runEntry, 0, len(s.runs))
+	var total int64
+	for id, e := range s.runs {
+		if e == nil || e.robot == nil {
+			continue
+		}
+		total += e.robotBytes
+		if !protected[id] {
+			loaded = append(loaded, e)
+		}
+	}
+	count := len(loaded) + len(protected)
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].lastUsed < loaded[j].lastUsed })
+
+	for _, e := range loaded {
+		overCount := s.opts.MaxLoadedRuns > 0 && count > s.opts.MaxLoadedRuns
+		overBytes := s.opts.MaxParsedBytes > 0 && total > s.opts.MaxParsedBytes
+		if !overCount && !overBytes {
+			break
+		}
+		total -= e.robotBytes
+		count--
+		e.robot = nil
+		e.robotBytes = 0
+		s.evictions++
+	}
+}
+
 func (s *RunStore) startBackgroundFill() {
 	s.fillMu.Lock()
 	if s.fillInProgress {
@@ -189,12 +414,18 @@ func (s *RunStore) startBackgroundFill() {
 
 		jobs := make(chan string)
 		var wg sync.WaitGroup
+		var hydratedMu sync.Mutex
+		hydrated := 0
 		for i := 0; i < workerCount; i++ {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 				for id := range jobs {
-					s.hydrateRun(id)
+					if s.hydrateRun(id) {
+						hydratedMu.Lock()
+						hydrated++
+						hydratedMu.Unlock()
+					}
 				}
 			}()
 		}
@@ -205,6 +436,7 @@ func (s *RunStore) startBackgroundFill() {
 		close(jobs)
 		wg.Wait()
 		s.persistCacheFromStore()
+		s.publish(Event{Type: EventHydrationFinished, Count: hydrated})
 	}()
 }
 
@@ -221,227 +453,395 @@ func (s *RunStore) collectIncompleteIDs() []string {
 		if entry.hotUntil.After(now) {
 			continue
 		}
-		if entry.statsIncomplete || entry.durationIncomplete {
+		if entry.incomplete() {
 			ids = append(ids, id)
 		}
 	}
 	return ids
 }
 
-func (s *RunStore) hydrateRun(id string) {
+// hydrateRun fills in missing stats/duration for a run and reports whether
+// anything changed.
+func (s *RunStore) hydrateRun(id string) bool {
 	s.mu.RLock()
 	entry := s.runs[id]
-	if entry == nil || (!entry.statsIncomplete && !entry.durationIncomplete) {
+	if entry == nil || !entry.incomplete() {
 		s.mu.RUnlock()
-		return
+		return false
 	}
-	abs := entry.abs
+	snapshot := *entry
 	s.mu.RUnlock()
+	src, parts := snapshot.source(), snapshot.parts
 
-	fi, err := os.Stat(abs)
+	modTime, size, err := snapshot.stat()
 	if err != nil {
-		return
+		return false
 	}
 
-	pass, fail, total, okStats, err := readRobotStatistics(abs)
-	if err != nil {
-		return
+	var summary runSummary
+	if len(parts) > 0 {
+		summary, err = readMergedSummary(parts)
+	} else {
+		summary, err = readRunSummary(src)
 	}
-
-	start, end, okTimes, err := readRobotMessageTimes(abs)
 	if err != nil {
-		return
+		return false
 	}
 	var durationMs int64
-	if okTimes && !start.IsZero() && !end.IsZero() && end.After(start) {
-		durationMs = end.Sub(start).Milliseconds()
+	if summary.okTimes && !summary.start.IsZero() && summary.end.After(summary.start) {
+		durationMs = summary.end.Sub(summary.start).Milliseconds()
+	}
+	var labels map[string]string
+	if snapshot.labelsIncomplete {
+		labels = s.runLabels(snapshot.info.RelPath, summary.metadata)
 	}
 
 	s.mu.Lock()
 	entry = s.runs[id]
 	if entry == nil {
 		s.mu.Unlock()
-		return
+		return false
 	}
-	entry.robotModTime = fi.ModTime()
-	entry.robotSize = fi.Size()
-	if entry.statsIncomplete && okStats {
-		entry.info.PassCount = pass
-		entry.info.FailCount = fail
-		entry.info.TestCount = total
+	changed := false
+	entry.robotModTime = modTime
+	entry.robotSize = size
+	if entry.statsIncomplete && summary.okStats {
+		entry.info.PassCount = summary.pass
+		entry.info.FailCount = summary.fail
+		entry.info.SkipCount = summary.skip
+		entry.info.TestCount = summary.total
 		entry.statsIncomplete = false
+		changed = true
 	}
-	if entry.durationIncomplete && okTimes {
+	if entry.durationIncomplete && summary.okTimes {
 		entry.info.DurationMs = durationMs
 		entry.durationIncomplete = false
+		changed = true
+	}
+	if entry.labelsIncomplete && snapshot.labelsIncomplete {
+		entry.info.Labels = labels
+		entry.labelsIncomplete = false
+		changed = true
 	}
+	if entry.errorsIncomplete {
+		entry.info.ErrorCount, entry.info.WarningCount = robodiff.CountExecutionErrors(summary.errors)
+		entry.errorsIncomplete = false
+		changed = true
+	}
+	info := entry.info
 	s.mu.Unlock()
+
+	if changed {
+		s.publish(Event{Type: EventRunUpdated, ID: id, Run: &info})
+	}
+	return changed
 }
 
-func (s *RunStore) scanOnce() {
-	// Build a fresh map each scan so deleted runs disappear.
-	updated := make(map[string]*runEntry, 128)
-	now := time.Now()
-	changed := false
+// scanState carries one scan pass: the entries seen before it started, the
+// entries it produced and whether any entry was added or modified.
+type scanState struct {
+	prev    map[string]*runEntry
+	updated map[string]*runEntry
+	now     time.Time
+	changed bool
+	// hot is set when the pass recorded a run that is still being written,
+	// so hydration has to be retried once it cools down.
+	hot bool
+
+	// prevEmpty and empty are the archives without results known before the
+	// pass and found by it (see RunStore.emptyArchives).
+	prevEmpty map[string]fileStamp
+	empty     map[string]fileStamp
+
+	prevByFile map[string][]*runEntry
+	// consumed holds the files merged into a logical run by this scan.
+	consumed map[string]struct{}
+}
+
+// prevForFile returns the entries the previous scan produced from the file at
+// abs; an archive yields one entry per result inside it.
+func (st *scanState) prevForFile(abs string) []*runEntry {
+	if st.prevByFile == nil {
+		st.prevByFile = make(map[string][]*runEntry, len(st.prev))
+		for _, e := range st.prev {
+			st.prevByFile[e.abs] = append(st.prevByFile[e.abs], e)
+		}
+	}
+	return st.prevByFile[abs]
+}
 
+// fileStamp identifies a version of a file on disk.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stampOf(fi os.FileInfo) fileStamp {
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+}
+
+// maxScanDepth allows nested layouts like root/run/output.xml or
+// root/env/run/output.xml.
+const maxScanDepth = 3
+
+func (s *RunStore) snapshotRuns() map[string]*runEntry {
 	s.mu.RLock()
-	prev := make(map[string]*runEntry, len(s.runs))
+	defer s.mu.RUnlock()
+	out := make(map[string]*runEntry, len(s.runs))
 	for k, v := range s.runs {
-		prev[k] = v
+		out[k] = v
 	}
-	s.mu.RUnlock()
+	return out
+}
 
-	const maxDepth = 3 // allow nested layouts like root/run/output.xml or root/env/run/output.xml
+func (s *RunStore) scanOnce() {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
 
-	var scanDir func(absDir string, depth int)
-	scanDir = func(absDir string, depth int) {
-		if depth > maxDepth {
-			return
-		}
+	// Build a fresh map each scan so deleted runs disappear.
+	st := &scanState{
+		prev:      s.snapshotRuns(),
+		updated:   make(map[string]*runEntry, 128),
+		now:       time.Now(),
+		prevEmpty: s.emptyArchives,
+		empty:     make(map[string]fileStamp),
+	}
 
-		entries, err := os.ReadDir(absDir)
-		if err != nil {
-			return
-		}
+	s.scanDir(st, s.dir, 0, true)
+	if len(st.updated) != len(st.prev) {
+		st.changed = true
+	}
+	s.commitScan(st)
+}
 
-		for _, ent := range entries {
-			name := ent.Name()
-			absPath := filepath.Join(absDir, name)
+// commitScan installs the result of a scan pass, persists the cache and
+// notifies subscribers when something changed.
+func (s *RunStore) commitScan(st *scanState) {
+	s.mu.Lock()
+	s.runs = st.updated
+	s.mu.Unlock()
+	s.emptyArchives = st.empty
+	if st.changed {
+		s.persistCacheFromStore()
+		s.publish(diffRuns(st.prev, st.updated)...)
+	}
 
-			isDir := ent.IsDir()
-			if !isDir && (ent.Type()&fs.ModeSymlink) != 0 {
-				// Follow symlinked directories (common when results are linked in).
-				if st, err := os.Stat(absPath); err == nil && st.IsDir() {
-					isDir = true
-				}
-			}
-			if isDir {
-				scanDir(absPath, depth+1)
-				continue
-			}
+	s.startBackgroundFill()
+}
 
-			lower := strings.ToLower(name)
-			if !strings.HasSuffix(lower, ".xml") {
-				continue
-			}
+// scanDir evaluates the run files in absDir, descending into subdirectories
+// when recursive is set.
+func (s *RunStore) scanDir(st *scanState, absDir string, depth int, recursive bool) {
+	if depth > maxScanDepth {
+		return
+	}
 
-			if !isRobotXMLFile(absPath) {
-				continue
-			}
+	entries, err := os.ReadDir(absDir)
+	if err != nil {
+		return
+	}
+	s.watchDir(absDir)
 
-			fi, err := os.Stat(absPath)
-			if err != nil {
-				continue
-			}
+	if parts := s.logicalRunParts(absDir); len(parts) > 0 {
+		s.scanLogicalRun(st, absDir, parts)
+	}
 
-			abs, err := filepath.Abs(absPath)
-			if err != nil {
-				continue
-			}
+	for _, ent := range entries {
+		name := ent.Name()
+		absPath := filepath.Join(absDir, name)
 
-			rel, err := filepath.Rel(s.dir, abs)
-			if err != nil {
-				rel = name
+		isDir := ent.IsDir()
+		if !isDir && (ent.Type()&fs.ModeSymlink) != 0 {
+			// Follow symlinked directories (common when results are linked in).
+			if fi, err := os.Stat(absPath); err == nil && fi.IsDir() {
+				isDir = true
 			}
+		}
+		if isDir {
+			if recursive {
+				s.scanDir(st, absPath, depth+1, true)
+			}
+			continue
+		}
 
-			id := stableID(abs)
+		s.scanFile(st, absPath, name)
+	}
+}
 
-			runName := ""
-			if lower == "output.xml" {
-				// Use directory name for output.xml so multiple runs don't all show as "output".
-				runName = filepath.Base(filepath.Dir(abs))
-			}
-			if runName == "" || runName == string(filepath.Separator) || lower != "output.xml" {
-				runName = strings.TrimSuffix(name, filepath.Ext(name))
-			}
+func (s *RunStore) scanFile(st *scanState, absPath, name string) {
+	isArchive := robodiff.IsArchiveFileName(name)
+	if !isArchive && !robodiff.IsResultFileName(name) {
+		return
+	}
 
-			runSize := runFolderSize(filepath.Dir(abs))
-			isHot := now.Sub(fi.ModTime()) < hotFileCooldown
+	fi, err := os.Stat(absPath)
+	if err != nil {
+		return
+	}
 
-			if existing, ok := prev[id]; ok && existing != nil {
-				if existing.info.ModTime.Equal(fi.ModTime()) && existing.info.Size == runSize {
-					updated[id] = existing
-					continue
-				}
-				changed = true
-				if isHot {
-					clone := *existing
-					clone.abs = abs
-					clone.info.ID = id
-					clone.info.Name = runName
-					clone.info.RelPath = filepath.ToSlash(rel)
-					clone.info.ModTime = fi.ModTime()
-					clone.info.Size = runSize
-					clone.statsIncomplete = true
-					clone.durationIncomplete = true
-					clone.hotUntil = now.Add(hotFileCooldown)
-					updated[id] = &clone
-					continue
-				}
-			}
-			changed = true
-
-			if isHot {
-				updated[id] = &runEntry{
-					abs: abs,
-					info: RunInfo{
-						ID:         id,
-						Name:       runName,
-						RelPath:    filepath.ToSlash(rel),
-						ModTime:    fi.ModTime(),
-						Size:       runSize,
-						DurationMs: 0,
-						TestCount:  0,
-						PassCount:  0,
-						FailCount:  0,
-					},
-					statsIncomplete:    true,
-					durationIncomplete: true,
-					hotUntil:            now.Add(hotFileCooldown),
-				}
-				continue
-			}
+	abs, err := filepath.Abs(absPath)
+	if err != nil {
+		return
+	}
+	if _, ok := st.consumed[abs]; ok {
+		return
+	}
 
-			pass, fail, total, okStats, err := readRobotStatisticsFast(abs)
-			if err != nil {
-				continue
+	rel, err := filepath.Rel(s.dir, abs)
+	if err != nil {
+		rel = name
+	}
+
+	if !isArchive {
+		format := robodiff.DetectFileFormat(abs)
+		if format == "" {
+			return
+		}
+		s.scanSource(st, robodiff.ResultSource{Path: abs, Format: format}, fi, rel)
+		return
+	}
+
+	// Listing an archive means decompressing it, so unchanged archives keep
+	// the entries of the previous scan, or stay skipped when they held no
+	// result (a logs bundle or a trace zip next to the run).
+	stamp := stampOf(fi)
+	if prevStamp, ok := st.prevEmpty[abs]; ok && prevStamp.modTime.Equal(stamp.modTime) && prevStamp.size == stamp.size {
+		st.empty[abs] = prevStamp
+		return
+	}
+	if prev := st.prevForFile(abs); len(prev) > 0 {
+		unchanged := true
+		for _, e := range prev {
+			if e.info.Archive == "" || !e.info.ModTime.Equal(fi.ModTime()) || e.info.Size != fi.Size() {
+				unchanged = false
+				break
 			}
-			statsIncomplete := !okStats
-			var durationMs int64
-			durationIncomplete := true
-
-			updated[id] = &runEntry{
-				abs: abs,
-				info: RunInfo{
-					ID:         id,
-					Name:       runName,
-					RelPath:    filepath.ToSlash(rel),
-					ModTime:    fi.ModTime(),
-					Size:       runSize,
-					DurationMs: durationMs,
-					TestCount:  total,
-					PassCount:  pass,
-					FailCount:  fail,
-				},
-				statsIncomplete:    statsIncomplete,
-				durationIncomplete: durationIncomplete,
+		}
+		if unchanged {
+			for _, e := range prev {
+				st.updated[e.info.ID] = e
 			}
+			return
 		}
 	}
+	sources := robodiff.DetectResultSources(abs)
+	if len(sources) == 0 {
+		st.empty[abs] = stamp
+		return
+	}
+	for _, src := range sources {
+		s.scanSource(st, src, fi, rel)
+	}
+}
 
-	scanDir(s.dir, 0)
-	if len(updated) != len(prev) {
-		changed = true
+// scanSource records one result found by scanFile. fi and rel describe the
+// file on disk holding it.
+func (s *RunStore) scanSource(st *scanState, src robodiff.ResultSource, fi os.FileInfo, rel string) {
+	abs := src.Path
+	id := runID(src)
+	runName := runNameForSource(src)
+	relPath := filepath.ToSlash(rel)
+	if src.Member != "" {
+		relPath += robodiff.MemberSeparator + src.Member
 	}
+	format := src.Format
 
-	s.mu.Lock()
-	s.runs = updated
-	s.mu.Unlock()
-	if changed {
-		s.persistCacheFromStore()
+	runSize := fi.Size()
+	if src.Archive == "" {
+		runSize = runFolderSize(filepath.Dir(abs))
 	}
+	isHot := st.now.Sub(fi.ModTime()) < hotFileCooldown
 
-	s.startBackgroundFill()
+	if existing, ok := st.prev[id]; ok && existing != nil {
+		if existing.info.ModTime.Equal(fi.ModTime()) && existing.info.Size == runSize {
+			st.updated[id] = existing
+			return
+		}
+		st.changed = true
+		if isHot {
+			clone := *existing
+			clone.abs = abs
+			clone.member = src.Member
+			clone.info.ID = id
+			clone.info.Name = runName
+			clone.info.RelPath = relPath
+			clone.info.ModTime = fi.ModTime()
+			clone.info.Size = runSize
+			clone.info.Format = format
+			clone.info.Archive = src.Archive
+			clone.info.Labels = s.pathLabels(relPath)
+			clone.statsIncomplete = true
+			clone.durationIncomplete = true
+			clone.labelsIncomplete = s.wantsMetadataLabels()
+			clone.errorsIncomplete = true
+			clone.hotUntil = st.now.Add(hotFileCooldown)
+			st.updated[id] = &clone
+			st.hot = true
+			return
+		}
+	}
+	st.changed = true
+
+	if isHot {
+		st.updated[id] = &runEntry{
+			abs:    abs,
+			member: src.Member,
+			info: RunInfo{
+				ID:         id,
+				Name:       runName,
+				RelPath:    relPath,
+				ModTime:    fi.ModTime(),
+				Size:       runSize,
+				DurationMs: 0,
+				TestCount:  0,
+				PassCount:  0,
+				FailCount:  0,
+				SkipCount:  0,
+				Format:     format,
+				Archive:    src.Archive,
+				Labels:     s.pathLabels(relPath),
+			},
+			statsIncomplete:    true,
+			durationIncomplete: true,
+			labelsIncomplete:   s.wantsMetadataLabels(),
+			errorsIncomplete:   true,
+			hotUntil:           st.now.Add(hotFileCooldown),
+		}
+		st.hot = true
+		return
+	}
+
+	pass, fail, skip, total, okStats, err := readRobotStatisticsFast(src)
+	if err != nil {
+		return
+	}
+	statsIncomplete := !okStats
+	var durationMs int64
+	durationIncomplete := true
+
+	st.updated[id] = &runEntry{
+		abs:    abs,
+		member: src.Member,
+		info: RunInfo{
+			ID:         id,
+			Name:       runName,
+			RelPath:    relPath,
+			ModTime:    fi.ModTime(),
+			Size:       runSize,
+			DurationMs: durationMs,
+			TestCount:  total,
+			PassCount:  pass,
+			FailCount:  fail,
+			SkipCount:  skip,
+			Format:     format,
+			Archive:    src.Archive,
+			Labels:     s.pathLabels(relPath),
+		},
+		statsIncomplete:    statsIncomplete,
+		durationIncomplete: durationIncomplete,
+		labelsIncomplete:   s.wantsMetadataLabels(),
+		errorsIncomplete:   true,
+	}
 }
 
 func (s *RunStore) loadCache() {
@@ -461,6 +861,7 @@ func (s *RunStore) loadCache() {
 	if snap.Version != runCacheVersion {
 		return
 	}
+	staleLabels := snap.Labels != s.labelConfigKey()
 
 	loaded := make(map[string]*runEntry, len(snap.Entries))
 	for _, item := range snap.Entries {
@@ -469,18 +870,26 @@ func (s *RunStore) loadCache() {
 		if id == "" || abs == "" {
 			continue
 		}
-		if stableID(abs) != id {
+		if runID(robodiff.ResultSource{Path: abs, Member: item.Member}) != id {
 			continue
 		}
 		entry := &runEntry{
 			info:               item.Info,
 			abs:                abs,
+			member:             item.Member,
+			parts:              item.Parts,
 			robotModTime:       item.RobotModTime,
 			robotSize:          item.RobotSize,
 			statsIncomplete:    item.StatsIncomplete,
 			durationIncomplete: item.DurationIncomplete,
+			labelsIncomplete:   item.LabelsIncomplete,
+			errorsIncomplete:   item.ErrorsIncomplete,
 		}
 		entry.info.ID = id
+		if staleLabels {
+			entry.info.Labels = s.pathLabels(entry.info.RelPath)
+			entry.labelsIncomplete = s.wantsMetadataLabels()
+		}
 		loaded[id] = entry
 	}
 
@@ -507,11 +916,15 @@ func (s *RunStore) persistCacheFromStore() {
 		entries = append(entries, runCacheEntry{
 			ID:                 id,
 			Abs:                e.abs,
+			Member:             e.member,
+			Parts:              e.parts,
 			Info:               e.info,
 			RobotModTime:       e.robotModTime,
 			RobotSize:          e.robotSize,
 			StatsIncomplete:    e.statsIncomplete,
 			DurationIncomplete: e.durationIncomplete,
+			LabelsIncomplete:   e.labelsIncomplete,
+			ErrorsIncomplete:   e.errorsIncomplete,
 		})
 	}
 	s.mu.RUnlock()
@@ -531,6 +944,7 @@ func (s *RunStore) persistCacheEntries(entries []runCacheEntry) {
 	snap := runCacheSnapshot{
 		Version: runCacheVersion,
 		Dir:     s.dir,
+		Labels:  s.labelConfigKey(),
 		SavedAt: time.Now(),
 		Entries: entries,
 	}
@@ -566,37 +980,57 @@ func cachePathForDir(dir string) (string, error) {
 	return filepath.Join(cacheRoot, "robodiff", "run-cache", hash+".json"), nil
 }
 
-func stableID(s string) string {
-	sum := sha256.Sum256([]byte(s))
-	return hex.EncodeToString(sum[:])
+// runID identifies a run by its file, plus the member for archive contents.
+func runID(src robodiff.ResultSource) string {
+	if src.Member != "" {
+		return stableID(src.Path + robodiff.MemberSeparator + src.Member)
+	}
+	return stableID(src.Path)
 }
 
-func isRobotXMLFile(path string) bool {
-	const maxProbeBytes = 64 * 1024
-	f, err := os.Open(path)
-	if err != nil {
-		return false
+// runNameForSource names a run after its file, or after the directory for
+// default outputs (output.xml, output.json) so multiple runs don't all show
+// as "output". Compression extensions are ignored, and archive members are
+// named from their path inside the archive, falling back to the archive name.
+func runNameForSource(src robodiff.ResultSource) string {
+	file := filepath.Base(src.Path)
+	dir := filepath.Base(filepath.Dir(src.Path))
+	if src.Member != "" {
+		file = path.Base(src.Member)
+		dir = path.Base(path.Dir(src.Member))
+		if dir == "." {
+			dir = trimResultExt(filepath.Base(src.Path))
+		}
+	} else if src.Archive == robodiff.ArchiveGzip {
+		file = file[:len(file)-len(filepath.Ext(file))]
 	}
-	defer f.Close()
 
-	buf := make([]byte, maxProbeBytes)
-	n, err := f.Read(buf)
-	if n <= 0 || err != nil && err != io.EOF {
-		return false
+	lower := strings.ToLower(file)
+	if lower == "output.xml" || lower == "output.json" {
+		if dir != "" && dir != string(filepath.Separator) && dir != "." {
+			return dir
+		}
 	}
+	return strings.TrimSuffix(file, filepath.Ext(file))
+}
 
-	dec := xml.NewDecoder(bytes.NewReader(buf[:n]))
+// trimResultExt strips result and archive extensions, including compound
+// ones like ".xml.gz" and ".tar.gz".
+func trimResultExt(name string) string {
 	for {
-		tok, err := dec.Token()
-		if err != nil {
-			return false
-		}
-		if se, ok := tok.(xml.StartElement); ok {
-			return strings.EqualFold(se.Name.Local, "robot")
+		ext := filepath.Ext(name)
+		if ext == "" || ext == name || !robodiff.IsResultFileName(name) && !robodiff.IsArchiveFileName(name) {
+			return name
 		}
+		name = strings.TrimSuffix(name, ext)
 	}
 }
 
+func stableID(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
 type robotStat struct {
 	Pass int    `xml:"pass,attr"`
 	Fail int    `xml:"fail,attr"`
@@ -604,51 +1038,25 @@ type robotStat struct {
 	Name string `xml:",chardata"`
 }
 
-func readRobotStatistics(path string) (pass, fail, total int, ok bool, err error) {
-	info, err := os.Stat(path)
-	if err != nil {
-		return 0, 0, 0, false, err
+// readRobotStatisticsFast only looks at the tail of plain files; compressed
+// results report !ok and are counted by background hydration.
+func readRobotStatisticsFast(src robodiff.ResultSource) (pass, fail, skip, total int, ok bool, err error) {
+	if !src.Seekable() {
+		return 0, 0, 0, 0, false, nil
 	}
-
-	// Fast path: read only the tail where <statistics> usually lives.
-	if info.Size() > 0 {
-		const maxTailBytes = 4 * 1024 * 1024
-		readSize := int64(maxTailBytes)
-		if info.Size() < readSize {
-			readSize = info.Size()
-		}
-		f, err := os.Open(path)
-		if err != nil {
-			return 0, 0, 0, false, err
-		}
-		buf := make([]byte, readSize)
-		_, _ = f.ReadAt(buf, info.Size()-readSize)
-		_ = f.Close()
-
-		if idx := bytes.LastIndex(buf, []byte("<statistics")); idx != -1 {
-			pass, fail, total, ok, err = scanStatisticsBytes(buf[idx:])
-			if err == nil && ok {
-				return pass, fail, total, ok, nil
-			}
-		}
-	}
-
-	// Fallback: stream entire file if tail scan couldn't find statistics.
-	f, err := os.Open(path)
-	if err != nil {
-		return 0, 0, 0, false, err
+	path := src.Path
+	switch src.Format {
+	case robodiff.FormatRobotJSON:
+		return readJSONStatistics(src)
+	case robodiff.FormatJUnit:
+		return readJUnitStatistics(src)
 	}
-	defer f.Close()
-	return scanStatisticsStream(xml.NewDecoder(f))
-}
-
-func readRobotStatisticsFast(path string) (pass, fail, total int, ok bool, err error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return 0, 0, 0, false, err
+		return 0, 0, 0, 0, false, err
 	}
 	if info.Size() <= 0 {
-		return 0, 0, 0, false, nil
+		return 0, 0, 0, 0, false, nil
 	}
 
 	const maxTailBytes = 4 * 1024 * 1024
@@ -658,106 +1066,157 @@ func readRobotStatisticsFast(path string) (pass, fail, total int, ok bool, err e
 	}
 	f, err := os.Open(path)
 	if err != nil {
-		return 0, 0, 0, false, err
+		return 0, 0, 0, 0, false, err
 	}
 	buf := make([]byte, readSize)
 	_, _ = f.ReadAt(buf, info.Size()-readSize)
 	_ = f.Close()
 
 	if idx := bytes.LastIndex(buf, []byte("<statistics")); idx != -1 {
-		pass, fail, total, ok, err = scanStatisticsBytes(buf[idx:])
+		pass, fail, skip, total, ok, err = scanStatisticsBytes(buf[idx:])
 		if err == nil && ok {
-			return pass, fail, total, ok, nil
+			return pass, fail, skip, total, ok, nil
 		}
 	}
-	return 0, 0, 0, false, nil
+	return 0, 0, 0, 0, false, nil
 }
 
-func readRobotMessageTimes(path string) (start, end time.Time, ok bool, err error) {
-	f, err := os.Open(path)
+// runSummary is what hydration reads from a run: test counts, the time
+// span, the top-level suite metadata and the execution errors.
+type runSummary struct {
+	pass, fail, skip, total int
+	okStats                 bool
+	start, end              time.Time
+	okTimes                 bool
+	metadata                []robodiff.Metadata
+	errors                  []robodiff.Message
+}
+
+// readRunSummary reads the summary of a single result in one pass.
+func readRunSummary(src robodiff.ResultSource) (runSummary, error) {
+	switch src.Format {
+	case robodiff.FormatRobotJSON:
+		return readJSONSummary(src)
+	case robodiff.FormatJUnit:
+		return readJUnitSummary(src)
+	}
+	f, err := src.Open()
 	if err != nil {
-		return time.Time{}, time.Time{}, false, err
+		return runSummary{}, err
 	}
 	defer f.Close()
 
+	// Tests are not decoded: the time span comes from the message times, the
+	// counts from <statistics>, and the metadata and <errors> are decoded
+	// where the walk meets them.
 	dec := xml.NewDecoder(f)
-	foundAny := false
-
+	var summary runSummary
+	noteTime := func(value string) {
+		t, ok := robodiff.ParseRobotTime(value)
+		if !ok {
+			return
+		}
+		if !summary.okTimes || t.Before(summary.start) {
+			summary.start = t
+		}
+		if !summary.okTimes || t.After(summary.end) {
+			summary.end = t
+		}
+		summary.okTimes = true
+	}
+	// depth is the element depth; rootDepth that of the top-level suite's
+	// children, whose <meta> elements are the run's metadata.
+	depth, rootDepth := 0, 0
 	for {
 		tok, err := dec.Token()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return time.Time{}, time.Time{}, false, err
+			return runSummary{}, err
 		}
 
 		switch se := tok.(type) {
 		case xml.StartElement:
-			if se.Name.Local != "msg" {
-				continue
-			}
-			var timeStr string
-			for _, a := range se.Attr {
-				if a.Name.Local == "time" {
-					timeStr = a.Value
-					break
-				}
-				if a.Name.Local == "timestamp" {
-					timeStr = a.Value
+			depth++
+			switch se.Name.Local {
+			case "suite":
+				if rootDepth == 0 {
+					rootDepth = depth + 1
 				}
-			}
-			if timeStr == "" {
 				continue
-			}
-			if t, ok := parseRobotTimestamp(timeStr); ok {
-				if !foundAny {
-					start = t
-					end = t
-					foundAny = true
-				} else {
-					if t.Before(start) {
-						start = t
+			case "msg":
+				var timeStr string
+				for _, a := range se.Attr {
+					if a.Name.Local == "time" {
+						timeStr = a.Value
+						break
 					}
-					if t.After(end) {
-						end = t
+					if a.Name.Local == "timestamp" {
+						timeStr = a.Value
 					}
 				}
+				noteTime(timeStr)
+				continue
+			case "meta", "metadata":
+				if depth != rootDepth {
+					continue
+				}
+				// Robot < 4 wraps metadata in <metadata><item name="..">..</item></metadata>.
+				var wrapped struct {
+					Items []robodiff.Metadata `xml:"item"`
+				}
+				var meta robodiff.Metadata
+				target := interface{}(&meta)
+				if se.Name.Local == "metadata" {
+					target = &wrapped
+				}
+				if err := dec.DecodeElement(target, &se); err != nil {
+					return runSummary{}, err
+				}
+				if se.Name.Local == "meta" {
+					summary.metadata = append(summary.metadata, meta)
+				} else {
+					summary.metadata = append(summary.metadata, wrapped.Items...)
+				}
+			case "statistics":
+				var stats robodiff.Statistics
+				if err := dec.DecodeElement(&stats, &se); err != nil {
+					return runSummary{}, err
+				}
+				if pass, fail, skip, ok := stats.Total.AllTests(); ok {
+					summary.pass, summary.fail, summary.skip = pass, fail, skip
+					summary.total = pass + fail + skip
+					summary.okStats = true
+				}
+			case "errors":
+				var errors struct {
+					Messages []robodiff.Message `xml:"msg"`
+				}
+				if err := dec.DecodeElement(&errors, &se); err != nil {
+					return runSummary{}, err
+				}
+				summary.errors = errors.Messages
+				for _, msg := range errors.Messages {
+					noteTime(msg.Timestamp)
+				}
+			default:
+				continue
 			}
+			// DecodeElement consumed the end element.
+			depth--
+		case xml.EndElement:
+			depth--
 		}
 	}
-
-	if foundAny {
-		return start, end, true, nil
-	}
-	return time.Time{}, time.Time{}, false, nil
-}
-
-func parseRobotTimestamp(value string) (time.Time, bool) {
-	if strings.TrimSpace(value) == "" {
-		return time.Time{}, false
-	}
-	layouts := []string{
-		time.RFC3339Nano,
-		"2006-01-02T15:04:05.000000",
-		"2006-01-02T15:04:05.000",
-		"2006-01-02T15:04:05",
-		"20060102 15:04:05.000",
-		"20060102 15:04:05",
-	}
-	for _, layout := range layouts {
-		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
-			return t, true
-		}
-	}
-	return time.Time{}, false
+	return summary, nil
 }
 
-func scanStatisticsBytes(b []byte) (pass, fail, total int, ok bool, err error) {
+func scanStatisticsBytes(b []byte) (pass, fail, skip, total int, ok bool, err error) {
 	return scanStatisticsStream(xml.NewDecoder(bytes.NewReader(b)))
 }
 
-func scanStatisticsStream(dec *xml.Decoder) (pass, fail, total int, ok bool, err error) {
+func scanStatisticsStream(dec *xml.Decoder) (pass, fail, skip, total int, ok bool, err error) {
 	insideStats := false
 	var fallback *robotStat
 
@@ -767,7 +1226,7 @@ func scanStatisticsStream(dec *xml.Decoder) (pass, fail, total int, ok bool, err
 			if err == io.EOF {
 				break
 			}
-			return 0, 0, 0, false, err
+			return 0, 0, 0, 0, false, err
 		}
 		switch se := tok.(type) {
 		case xml.StartElement:
@@ -780,11 +1239,11 @@ func scanStatisticsStream(dec *xml.Decoder) (pass, fail, total int, ok bool, err
 				}
 				var st robotStat
 				if err := dec.DecodeElement(&st, &se); err != nil {
-					return 0, 0, 0, false, err
+					return 0, 0, 0, 0, false, err
 				}
 				name := strings.TrimSpace(st.Name)
 				if strings.EqualFold(name, "All Tests") {
-					return st.Pass, st.Fail, st.Pass + st.Fail + st.Skip, true, nil
+					return st.Pass, st.Fail, st.Skip, st.Pass + st.Fail + st.Skip, true, nil
 				}
 				if fallback == nil {
 					fallback = &st
@@ -793,16 +1252,16 @@ func scanStatisticsStream(dec *xml.Decoder) (pass, fail, total int, ok bool, err
 		case xml.EndElement:
 			if insideStats && se.Name.Local == "statistics" {
 				if fallback != nil {
-					return fallback.Pass, fallback.Fail, fallback.Pass + fallback.Fail + fallback.Skip, true, nil
+					return fallback.Pass, fallback.Fail, fallback.Skip, fallback.Pass + fallback.Fail + fallback.Skip, true, nil
 				}
-				return 0, 0, 0, false, nil
+				return 0, 0, 0, 0, false, nil
 			}
 		}
 	}
 	if fallback != nil {
-		return fallback.Pass, fallback.Fail, fallback.Pass + fallback.Fail + fallback.Skip, true, nil
+		return fallback.Pass, fallback.Fail, fallback.Skip, fallback.Pass + fallback.Fail + fallback.Skip, true, nil
 	}
-	return 0, 0, 0, false, nil
+	return 0, 0, 0, 0, false, nil
 }
 
 func (s *RunStore) GetTestDetails(ctx context.Context, runID, testName string) (*robodiff.Test, error) {
@@ -816,7 +1275,10 @@ func (s *RunStore) GetTestDetails(ctx context.Context, runID, testName string) (
 		s.mu.Unlock()
 		return nil, err
 	}
+	s.touchLocked(entry)
 	robot := entry.robot
+	snapshot := *entry
+	s.evictLocked(runID)
 	s.mu.Unlock()
 
 	// Search for the test in the cached robot data
@@ -827,6 +1289,18 @@ func (s *RunStore) GetTestDetails(ctx context.Context, runID, testName string) (
 	if test == nil {
 		test = findTestInSuite(&robot.Suite, testName)
 	}
+	if test != nil && test.BodyTruncated {
+		// The cached tree was trimmed by the parse options; decode this one
+		// test in full straight from the file.
+		src := snapshot.testSource(test)
+		full, err := loadFullTest(ctx, src, test, testName)
+		if err != nil {
+			return nil, fmt.Errorf("parse run %s: %w", src, err)
+		}
+		if full != nil {
+			return full, nil
+		}
+	}
 	if test != nil {
 		return test, nil
 	}
@@ -834,6 +1308,22 @@ func (s *RunStore) GetTestDetails(ctx context.Context, runID, testName string) (
 	return nil, fmt.Errorf("test %q not found in run", testName)
 }
 
+// loadFullTest re-reads a trimmed test, seeking to its recorded byte range
+// when available and falling back to a streaming search by name. Compressed
+// results cannot seek and are always searched.
+func loadFullTest(ctx context.Context, src robodiff.ResultSource, test *robodiff.Test, testName string) (*robodiff.Test, error) {
+	if test.Length > 0 && src.Seekable() {
+		full, err := robodiff.ReadTestAtFileContext(ctx, src.Path, test.Offset, test.Length)
+		if err == nil && full.Name == test.Name {
+			return full, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+	}
+	return robodiff.FindTestInSourceContext(ctx, src, testName)
+}
+
 func (s *RunStore) RunFilePath(runID string) (string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -845,38 +1335,65 @@ func (s *RunStore) RunFilePath(runID string) (string, error) {
 	return entry.abs, nil
 }
 
+// RunSource returns where a run's result is read from, including the member
+// for runs stored inside an archive.
+func (s *RunStore) RunSource(runID string) (robodiff.ResultSource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry := s.runs[runID]
+	if entry == nil {
+		return robodiff.ResultSource{}, errRunNotFound
+	}
+	return entry.source(), nil
+}
+
 func (s *RunStore) ensureRobotLoadedLocked(ctx context.Context, entry *runEntry) error {
-	fi, err := os.Stat(entry.abs)
+	modTime, size, err := entry.stat()
 	if err != nil {
 		return fmt.Errorf("stat run %s: %w", entry.abs, err)
 	}
 
-	if entry.robot != nil && entry.robotModTime.Equal(fi.ModTime()) && entry.robotSize == fi.Size() {
+	if entry.robot != nil && entry.robotModTime.Equal(modTime) && entry.robotSize == size {
 		return nil
 	}
 
-	robot, err := robodiff.ParseRobotXMLFileContext(ctx, entry.abs)
+	src := entry.source()
+	var robot *robodiff.Robot
+	if len(entry.parts) > 0 {
+		robot, err = parseMergedRun(ctx, entry.parts, s.opts.Parse)
+	} else {
+		robot, err = robodiff.ParseResultSourceWithOptions(ctx, src, s.opts.Parse)
+	}
 	if err != nil {
-		return fmt.Errorf("parse run %s: %w", entry.abs, err)
+		return fmt.Errorf("parse run %s: %w", src, err)
 	}
 	entry.robot = robot
-	entry.robotModTime = fi.ModTime()
-	entry.robotSize = fi.Size()
+	entry.robotBytes = robot.ApproxMemory()
+	entry.robotModTime = modTime
+	entry.robotSize = size
 	if entry.statsIncomplete {
-		pass, fail, total := robodiff.CountTests(&robot.Suite)
+		pass, fail, skip, total := robodiff.CountTests(&robot.Suite)
 		entry.info.PassCount = pass
 		entry.info.FailCount = fail
+		entry.info.SkipCount = skip
 		entry.info.TestCount = total
 		entry.statsIncomplete = false
 	}
 	if entry.durationIncomplete {
-		start, okStart := parseRobotTimestamp(robot.Suite.Status.StartTime)
-		end, okEnd := parseRobotTimestamp(robot.Suite.Status.EndTime)
-		if okStart && okEnd && !start.IsZero() && !end.IsZero() && end.After(start) {
+		if start, end, ok := statusTimes(robot.Suite.Status); ok && end.After(start) {
 			entry.info.DurationMs = end.Sub(start).Milliseconds()
 		}
 		entry.durationIncomplete = false
 	}
+	if entry.labelsIncomplete {
+		entry.info.Labels = s.runLabels(entry.info.RelPath, robot.Suite.Metadata)
+		entry.labelsIncomplete = false
+	}
+	if entry.errorsIncomplete {
+		entry.info.ErrorCount, entry.info.WarningCount = robodiff.CountExecutionErrors(robot.Errors)
+		entry.errorsIncomplete = false
+	}
 	return nil
 }
 
@@ -896,14 +1413,24 @@ func (s *RunStore) DeleteRuns(ids []string) (deleted int, err error) {
 	}
 
 	// Copy the run files while holding the lock; delete outside the lock.
+	// Runs inside an archive share its file, which goes away as a whole.
 	runFiles := make([]string, 0, len(ids))
+	seenFiles := make(map[string]struct{}, len(ids))
+	logicalDirs := make(map[string]bool)
 	s.mu.RLock()
 	for _, id := range ids {
 		e := s.runs[id]
 		if e == nil {
 			continue
 		}
+		if _, ok := seenFiles[e.abs]; ok {
+			continue
+		}
+		seenFiles[e.abs] = struct{}{}
 		runFiles = append(runFiles, e.abs)
+		if len(e.parts) > 0 {
+			logicalDirs[e.abs] = true
+		}
 	}
 	s.mu.RUnlock()
 
@@ -918,6 +1445,13 @@ func (s *RunStore) DeleteRuns(ids []string) (deleted int, err error) {
 		}
 
 		dirReal := filepath.Dir(fileReal)
+		if logicalDirs[file] {
+			// A logical run is its directory.
+			dirReal = fileReal
+			if samePath(rootReal, dirReal) {
+				return deleted, errors.New("refusing to delete the runs root")
+			}
+		}
 
 		if !isSubpath(rootReal, dirReal) {
 			return deleted, fmt.Errorf("refusing to delete outside runs root: %s", dirReal)
@@ -974,6 +1508,9 @@ func (s *RunStore) RenameRun(id, newName string) error {
 	if entry == nil {
 		return errRunNotFound
 	}
+	if entry.member != "" {
+		return errors.New("runs inside an archive cannot be renamed")
+	}
 
 	fileAbs, err := filepath.Abs(entry.abs)
 	if err != nil {
@@ -985,13 +1522,21 @@ func (s *RunStore) RenameRun(id, newName string) error {
 	}
 
 	dirReal := filepath.Dir(fileReal)
+	if len(entry.parts) > 0 {
+		// A logical run is its directory.
+		dirReal = fileReal
+		if samePath(rootReal, dirReal) {
+			return errors.New("the runs root cannot be renamed")
+		}
+	}
 	if !isSubpath(rootReal, dirReal) {
 		return fmt.Errorf("refusing to rename outside runs root: %s", dirReal)
 	}
 
 	if samePath(rootReal, dirReal) {
-		// If XML is in the root, rename the XML file itself.
-		targetFile := filepath.Join(rootReal, normalized+".xml")
+		// If the result file is in the root, rename the file itself.
+		base := filepath.Base(fileReal)
+		targetFile := filepath.Join(rootReal, normalized+base[len(trimResultExt(base)):])
 		if samePath(fileReal, targetFile) {
 			if exactSamePath(fileReal, targetFile) {
 				return nil
@@ -1087,7 +1632,7 @@ func exactSamePath(a, b string) bool {
 }
 
 func runFolderSize(dir string) int64 {
-	files := []string{"output.xml", "log.html", "report.html"}
+	files := []string{"output.xml", "output.json", "output.xml.gz", "output.json.gz", "log.html", "report.html"}
 	var total int64
 	for _, name := range files {
 		st, err := os.Stat(filepath.Join(dir, name))
@@ -1118,9 +1663,7 @@ func uniqueNonEmptyStrings(ids []string) []string {
 
 func normalizeRunName(name string) (string, error) {
 	name = strings.TrimSpace(name)
-	if strings.HasSuffix(strings.ToLower(name), ".xml") {
-		name = strings.TrimSpace(strings.TrimSuffix(name, filepath.Ext(name)))
-	}
+	name = strings.TrimSpace(trimResultExt(name))
 	if name == "" || name == "." || name == ".." {
 		return "", errors.New("invalid run name")
 	}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fixtureRun is the Robot Framework 7 output shared with the robodiff tests,
+// made absolute for tests that change the working directory.
+var fixtureRun, _ = filepath.Abs("../diff/testdata/output.xml")
+
+// writeResult copies the fixture run to rel below dir and dates it age ago,
+// past the hot-file cooldown unless age is small.
+func writeResult(t *testing.T, dir, rel string, age time.Duration) string {
+	t.Helper()
+	data, err := os.ReadFile(fixtureRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	when := time.Now().Add(-age)
+	if err := os.Chtimes(path, when, when); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newTestStore returns a store over dir whose run cache lives in a temporary
+// directory. Background hydration is waited for when the test ends.
+func newTestStore(t *testing.T, dir string, opts Options) *RunStore {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	s := NewRunStore(dir, time.Hour, opts)
+	t.Cleanup(func() { waitForFill(t, s) })
+	return s
+}
+
+// waitForFill waits until background hydration is done.
+func waitForFill(t *testing.T, s *RunStore) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		s.fillMu.Lock()
+		busy := s.fillInProgress
+		s.fillMu.Unlock()
+		if !busy {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background hydration did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// runIDs maps the relative path of each listed run to its id.
+func runIDs(s *RunStore) map[string]string {
+	ids := make(map[string]string)
+	for _, info := range s.ListRuns() {
+		ids[info.RelPath] = info.ID
+	}
+	return ids
+}
+
+// relPaths lists the relative paths of the listed runs, sorted.
+func relPaths(s *RunStore) []string {
+	var paths []string
+	for _, info := range s.ListRuns() {
+		paths = append(paths, info.RelPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// loadedRuns lists the relative paths of the runs whose parsed result is
+// held, sorted.
+func loadedRuns(s *RunStore) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var paths []string
+	for _, e := range s.runs {
+		if e.robot != nil {
+			paths = append(paths, e.info.RelPath)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestGetRunsEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		writeResult(t, dir, name+"/output.xml", time.Hour)
+	}
+	s := newTestStore(t, dir, Options{MaxLoadedRuns: 2})
+	s.ScanOnce()
+	ids := runIDs(s)
+	ctx := context.Background()
+
+	steps := []struct {
+		get    []string
+		loaded []string
+	}{
+		{[]string{"a"}, []string{"a/output.xml"}},
+		{[]string{"b"}, []string{"a/output.xml", "b/output.xml"}},
+		{[]string{"c"}, []string{"b/output.xml", "c/output.xml"}},
+		{[]string{"b"}, []string{"b/output.xml", "c/output.xml"}},
+		{[]string{"a"}, []string{"a/output.xml", "b/output.xml"}},
+		// Runs of one request are kept even beyond the budget.
+		{[]string{"a", "b", "c"}, []string{"a/output.xml", "b/output.xml", "c/output.xml"}},
+		{[]string{"c"}, []string{"b/output.xml", "c/output.xml"}},
+	}
+	for i, step := range steps {
+		var get []string
+		for _, name := range step.get {
+			get = append(get, ids[name+"/output.xml"])
+		}
+		if _, _, robots, err := s.GetRuns(ctx, get); err != nil || len(robots) != len(get) {
+			t.Fatalf("step %d: GetRuns(%v): %d results, %v", i, step.get, len(robots), err)
+		}
+		if got := loadedRuns(s); !slices.Equal(got, step.loaded) {
+			t.Errorf("step %d: after getting %v loaded %v, want %v", i, step.get, got, step.loaded)
+		}
+	}
+	if got := s.MemoryUsage().Evictions; got != 3 {
+		t.Errorf("%d evictions, want 3", got)
+	}
+}
+
+func TestGetRunsEvictsOverByteBudget(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		writeResult(t, dir, name+"/output.xml", time.Hour)
+	}
+	s := newTestStore(t, dir, Options{})
+	s.ScanOnce()
+	ids := runIDs(s)
+	ctx := context.Background()
+
+	if _, _, _, err := s.GetRuns(ctx, []string{ids["a/output.xml"]}); err != nil {
+		t.Fatal(err)
+	}
+	// A budget of one parsed run.
+	s.opts.MaxParsedBytes = s.MemoryUsage().ParsedBytes
+	if s.opts.MaxParsedBytes <= 0 {
+		t.Fatalf("parsed run has no estimated size")
+	}
+	if _, _, _, err := s.GetRuns(ctx, []string{ids["b/output.xml"]}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loadedRuns(s), []string{"b/output.xml"}; !slices.Equal(got, want) {
+		t.Errorf("loaded %v, want %v", got, want)
+	}
+	if usage := s.MemoryUsage(); usage.ParsedBytes > usage.MaxParsedBytes {
+		t.Errorf("%d parsed bytes over the budget of %d", usage.ParsedBytes, usage.MaxParsedBytes)
+	}
+}
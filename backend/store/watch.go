@@ -0,0 +1,227 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Watch modes reported by RunStore.WatchMode.
+const (
+	WatchModePoll    = "poll"
+	WatchModeInotify = "inotify"
+)
+
+const (
+	// watchDebounce groups bursts of events (e.g. Robot writing output.xml)
+	// into a single incremental update once they have been quiet this long.
+	watchDebounce = 500 * time.Millisecond
+	// watchMaxDelay bounds how long a steady stream of events (a long run
+	// appending to output.xml) can hold back an update.
+	watchMaxDelay = 5 * time.Second
+	// DefaultReconcileInterval is how often watch mode still runs a full scan
+	// to catch anything the watcher missed.
+	DefaultReconcileInterval = 5 * time.Minute
+)
+
+var errWatchUnsupported = errors.New("file watching is not supported on this platform")
+
+// watchEvent is a change below a watched directory. Overflow means the kernel
+// dropped events and a full rescan is needed.
+type watchEvent struct {
+	Path     string
+	IsDir    bool
+	Overflow bool
+}
+
+type dirWatcher interface {
+	Add(dir string) error
+	Events() <-chan watchEvent
+}
+
+// WatchMode reports whether the store reacts to file system events or polls.
+func (s *RunStore) WatchMode() string {
+	if s.watching.Load() {
+		return WatchModeInotify
+	}
+	return WatchModePoll
+}
+
+func (s *RunStore) reconcileInterval() time.Duration {
+	if s.opts.ReconcileInterval <= 0 {
+		return DefaultReconcileInterval
+	}
+	return s.opts.ReconcileInterval
+}
+
+// watchDir registers absDir with the watcher, if any. Failures (for example
+// running out of inotify watches) are left to the periodic reconcile.
+func (s *RunStore) watchDir(absDir string) {
+	if s.watcher == nil {
+		return
+	}
+	_ = s.watcher.Add(absDir)
+}
+
+func (s *RunStore) watchLoop() {
+	s.scanOnce()
+
+	reconcile := time.NewTicker(s.reconcileInterval())
+	defer reconcile.Stop()
+
+	events := s.watcher.Events()
+	var pending []watchEvent
+	// flush fires once no event has arrived for watchDebounce, or
+	// watchMaxDelay after the first pending event while a file keeps
+	// changing.
+	flush := time.NewTimer(watchDebounce)
+	flush.Stop()
+	defer flush.Stop()
+	var firstPending time.Time
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				// The watcher died; fall back to polling.
+				s.scanMu.Lock()
+				s.watcher = nil
+				s.watching.Store(false)
+				s.scanMu.Unlock()
+				s.scanLoop()
+				return
+			}
+			if len(pending) == 0 {
+				firstPending = time.Now()
+			} else if !flush.Stop() {
+				<-flush.C
+			}
+			pending = append(pending, ev)
+			delay := watchDebounce
+			if left := watchMaxDelay - time.Since(firstPending); left < delay {
+				delay = max(left, 0)
+			}
+			flush.Reset(delay)
+		case <-flush.C:
+			s.applyWatchEvents(pending)
+			pending = nil
+		case <-reconcile.C:
+			s.scanOnce()
+		}
+	}
+}
+
+// applyWatchEvents re-evaluates only the paths touched by events: a changed
+// file re-evaluates the run files in its directory (the run size also depends
+// on log.html/report.html), a created or removed directory rescans that
+// subtree, and anything inside a logical run rescans that run.
+func (s *RunStore) applyWatchEvents(events []watchEvent) {
+	// Anything inside a logical run rescans the whole run so its parts are
+	// not listed on their own.
+	logical := make(map[string]struct{})
+	for _, e := range s.snapshotRuns() {
+		if len(e.parts) > 0 {
+			logical[e.abs] = struct{}{}
+		}
+	}
+
+	subtrees := make(map[string]struct{})
+	flatDirs := make(map[string]struct{})
+	for _, ev := range events {
+		if ev.Overflow {
+			s.scanOnce()
+			return
+		}
+		if dir, ok := s.logicalRunDir(ev.Path, logical); ok {
+			subtrees[filepath.Clean(dir)] = struct{}{}
+			continue
+		}
+		if ev.IsDir {
+			subtrees[filepath.Clean(ev.Path)] = struct{}{}
+		} else {
+			flatDirs[filepath.Dir(ev.Path)] = struct{}{}
+		}
+	}
+
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	prev := s.snapshotRuns()
+	st := &scanState{
+		prev:      prev,
+		updated:   make(map[string]*runEntry, len(prev)),
+		now:       time.Now(),
+		prevEmpty: s.emptyArchives,
+		empty:     make(map[string]fileStamp, len(s.emptyArchives)),
+	}
+	for id, e := range prev {
+		st.updated[id] = e
+	}
+	for abs, stamp := range s.emptyArchives {
+		st.empty[abs] = stamp
+	}
+
+	for dir := range subtrees {
+		for id, e := range st.updated {
+			if isSubpath(dir, e.abs) {
+				delete(st.updated, id)
+			}
+		}
+		for abs := range st.empty {
+			if isSubpath(dir, abs) {
+				delete(st.empty, abs)
+			}
+		}
+		if depth, ok := s.dirDepth(dir); ok && isExistingDir(dir) {
+			s.scanDir(st, dir, depth, true)
+		}
+	}
+	for dir := range flatDirs {
+		if _, covered := subtrees[dir]; covered {
+			continue
+		}
+		for id, e := range st.updated {
+			if samePath(filepath.Dir(e.abs), dir) {
+				delete(st.updated, id)
+			}
+		}
+		for abs := range st.empty {
+			if samePath(filepath.Dir(abs), dir) {
+				delete(st.empty, abs)
+			}
+		}
+		if depth, ok := s.dirDepth(dir); ok && isExistingDir(dir) {
+			s.scanDir(st, dir, depth, false)
+		}
+	}
+
+	if len(st.updated) != len(st.prev) {
+		st.changed = true
+	}
+	s.commitScan(st)
+
+	// Hot files are hydrated once they have been quiet for the cooldown.
+	if st.hot {
+		time.AfterFunc(hotFileCooldown+watchDebounce, s.startBackgroundFill)
+	}
+}
+
+// dirDepth returns how deep dir is below the store root, or false when dir is
+// outside of it.
+func (s *RunStore) dirDepth(dir string) (int, bool) {
+	rel, err := filepath.Rel(s.dir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return 0, false
+	}
+	if rel == "." {
+		return 0, true
+	}
+	return len(strings.Split(rel, string(filepath.Separator))), true
+}
+
+func isExistingDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
@@ -0,0 +1,120 @@
+//go:build linux
+
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MODIFY | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB
+
+// inotifyWatcher watches directories (non-recursively) with raw inotify
+// syscalls so we don't need an external dependency.
+type inotifyWatcher struct {
+	fd     int
+	events chan watchEvent
+
+	mu    sync.Mutex
+	wds   map[int]string
+	paths map[string]int
+}
+
+func newDirWatcher() (dirWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	w := &inotifyWatcher{
+		fd:     fd,
+		events: make(chan watchEvent, 256),
+		wds:    make(map[int]string),
+		paths:  make(map[string]int),
+	}
+	go w.readLoop()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan watchEvent { return w.events }
+
+func (w *inotifyWatcher) Add(dir string) error {
+	dir = filepath.Clean(dir)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.paths[dir]; ok {
+		return nil
+	}
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+	// The same inode may be reached through a symlink; keep the first path.
+	if _, ok := w.wds[wd]; !ok {
+		w.wds[wd] = dir
+	}
+	w.paths[dir] = wd
+	return nil
+}
+
+func (w *inotifyWatcher) readLoop() {
+	defer close(w.events)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := syscall.Read(w.fd, buf)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(raw.Len)
+			if nameEnd > n {
+				break
+			}
+			name := strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00")
+			offset = nameEnd
+
+			if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				w.events <- watchEvent{Overflow: true}
+				continue
+			}
+			if raw.Mask&syscall.IN_IGNORED != 0 {
+				w.forget(int(raw.Wd))
+				continue
+			}
+
+			w.mu.Lock()
+			dir, ok := w.wds[int(raw.Wd)]
+			w.mu.Unlock()
+			if !ok || name == "" {
+				continue
+			}
+			w.events <- watchEvent{
+				Path:  filepath.Join(dir, name),
+				IsDir: raw.Mask&syscall.IN_ISDIR != 0,
+			}
+		}
+	}
+}
+
+// forget drops a watch the kernel removed (directory deleted or moved away).
+func (w *inotifyWatcher) forget(wd int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dir, ok := w.wds[wd]
+	if !ok {
+		return
+	}
+	delete(w.wds, wd)
+	if w.paths[dir] == wd {
+		delete(w.paths, dir)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package store
+
+func newDirWatcher() (dirWatcher, error) {
+	return nil, errWatchUnsupported
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchPicksUpNewRun(t *testing.T) {
+	for _, relative := range []bool{false, true} {
+		name := "absolute dir"
+		if relative {
+			name = "relative dir"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeResult(t, dir, "old/output.xml", time.Hour)
+			storeDir := dir
+			if relative {
+				chdir(t, dir)
+				storeDir = "."
+			}
+			s := newTestStore(t, storeDir, Options{Watch: true})
+			events, stop := s.Subscribe()
+			defer stop()
+			s.Start()
+			if s.WatchMode() != WatchModeInotify {
+				t.Skip("file watching is not supported on this platform")
+			}
+			waitForRun(t, s, events, "old/output.xml")
+
+			// A new directory and a file in it, dated past the hot-file
+			// cooldown so the run is hydrated at once.
+			writeResult(t, dir, "run1/output.xml", time.Hour)
+			info := waitForRun(t, s, events, "run1/output.xml")
+			if info.TestCount != 3 {
+				t.Errorf("run1: %d tests, want 3", info.TestCount)
+			}
+		})
+	}
+}
+
+// waitForRun waits for the run at relPath to be listed with its statistics,
+// well before the periodic reconcile would find it.
+func waitForRun(t *testing.T, s *RunStore, events <-chan Event, relPath string) RunInfo {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		for _, info := range s.ListRuns() {
+			if info.RelPath == relPath && info.TestCount > 0 {
+				return info
+			}
+		}
+		select {
+		case <-events:
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("%s not listed; runs %v", relPath, relPaths(s))
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	robodiff "robot_diff/backend/diff"
+)
+
+const diffUsage = `robodiff diff: compare Robot Framework outputs without starting the server
+
+Usage:
+	robodiff diff [options] <output.xml> <output.xml> [<output.xml>...]
+
+Parses the given output files (Robot XML, RF 7 JSON or JUnit/xUnit XML) and
+prints a comparison. Files may be gzip-compressed or zip/tar(.gz) archives
+holding a single result; pick one result of a larger archive with
+'archive.zip!/dir/output.xml'. Exits with status 1 when a test goes from PASS to FAIL
+between two adjacent files, 2 on usage or parse errors and 0 otherwise.
+
+Options:
+	--format fmt    Output format: text, json or markdown. Default: text.
+	--title title   Report title. Default: 'Robodiff'.
+	--names list    Comma-separated column names. Default: derived from paths.
+	--changed-only  Only list tests whose result differs between columns.
+	--include tags  Comma-separated tag patterns; only matching tests are compared.
+	                Patterns work as in robot: '*' and '?' are wildcards and
+	                tags combine with AND, OR and NOT, e.g. 'smokeANDapi'.
+	--exclude tags  Comma-separated tag patterns; matching tests are skipped.
+	--exact-names   Match tests by long name only. By default a test that was
+	                renamed or moved is matched to its earlier results.
+	-h, --help      Print this usage instruction.
+
+Examples:
+	robodiff diff nightly/output.xml pr/output.xml
+	robodiff diff --format markdown --changed-only a.xml b.xml > diff.md
+	robodiff diff nightly.tar.gz 'results.zip!/pr/output.xml'
+`
+
+type diffConfig struct {
+	Help        bool
+	Format      string
+	Title       string
+	Names       string
+	ChangedOnly bool
+	Include     string
+	Exclude     string
+	ExactNames  bool
+}
+
+func runDiffCommand(args []string, stdout, stderr io.Writer) int {
+	config := &diffConfig{}
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&config.Help, "h", false, "Show help")
+	fs.BoolVar(&config.Help, "help", false, "Show help")
+	fs.StringVar(&config.Format, "format", "text", "Output format")
+	fs.StringVar(&config.Title, "title", "Robodiff", "Report title")
+	fs.StringVar(&config.Names, "names", "", "Comma-separated column names")
+	fs.BoolVar(&config.ChangedOnly, "changed-only", false, "Only list changed tests")
+	fs.StringVar(&config.Include, "include", "", "Comma-separated tag patterns to include")
+	fs.StringVar(&config.Exclude, "exclude", "", "Comma-separated tag patterns to exclude")
+	fs.BoolVar(&config.ExactNames, "exact-names", false, "Match tests by long name only")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, diffUsage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if config.Help {
+		fmt.Fprint(stdout, diffUsage)
+		return 0
+	}
+
+	files := fs.Args()
+	if len(files) < 2 {
+		fmt.Fprintln(stderr, "Error: expected at least two output files")
+		fmt.Fprint(stderr, diffUsage)
+		return 2
+	}
+
+	columns := make([]string, len(files))
+	for i, file := range files {
+		columns[i] = columnNameForFile(file)
+	}
+	if config.Names != "" {
+		names := strings.Split(config.Names, ",")
+		if len(names) != len(files) {
+			fmt.Fprintf(stderr, "Error: --names has %d entries but %d files were given\n", len(names), len(files))
+			return 2
+		}
+		for i, name := range names {
+			columns[i] = strings.TrimSpace(name)
+		}
+	}
+
+	filter := robodiff.NewTagFilter(splitList(config.Include), splitList(config.Exclude))
+	results := robodiff.NewDiffResultsWithOptions(robodiff.MatchOptions{ExactNames: config.ExactNames})
+	for i, file := range files {
+		robot, err := robodiff.ParseResultFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: parse %s: %v\n", file, err)
+			return 2
+		}
+		results.AddParsedOutput(filter.Apply(robot), columns[i])
+	}
+
+	report := robodiff.NewDiffReporter(config.Title, columns, files).BuildJSONData(results)
+
+	var err error
+	switch strings.ToLower(config.Format) {
+	case "text", "txt":
+		err = robodiff.WriteText(stdout, report, config.ChangedOnly)
+	case "json":
+		err = robodiff.WriteJSON(stdout, report)
+	case "markdown", "md":
+		err = robodiff.WriteMarkdown(stdout, report, config.ChangedOnly)
+	default:
+		fmt.Fprintf(stderr, "Error: unknown format %q\n", config.Format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: write report: %v\n", err)
+		return 2
+	}
+
+	if len(report.Regressions()) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// columnNameForFile mirrors how the run store names runs: output.xml and
+// output.json take the name of their directory (inside the archive for
+// archive members), other files use their base name without extension.
+func columnNameForFile(path string) string {
+	file, member := robodiff.SplitMemberPath(path)
+	if member != "" {
+		return columnNameForFile(filepath.Join(trimArchiveExt(filepath.Base(file)), filepath.FromSlash(member)))
+	}
+	name := trimArchiveExt(filepath.Base(path))
+	if strings.EqualFold(name, "output.xml") || strings.EqualFold(name, "output.json") {
+		if abs, err := filepath.Abs(path); err == nil {
+			dir := filepath.Base(filepath.Dir(abs))
+			if dir != "" && dir != string(filepath.Separator) && dir != "." {
+				return dir
+			}
+		}
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// trimArchiveExt strips ".gz", ".tgz", ".zip" and ".tar" suffixes.
+func trimArchiveExt(name string) string {
+	for robodiff.IsArchiveFileName(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// diffFixtures writes the robodiff fixture run as base/output.xml and a copy
+// where Valid Login fails as head/output.xml.
+func diffFixtures(t *testing.T) (base, head string) {
+	t.Helper()
+	data, err := os.ReadFile("backend/diff/testdata/output.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass := `<status status="PASS" start="2024-05-02T10:00:00.200000" elapsed="0.700000"/>`
+	fail := `<status status="FAIL" start="2024-05-02T10:00:00.200000" elapsed="0.700000">Token expired</status>`
+	if !bytes.Contains(data, []byte(pass)) {
+		t.Fatal("fixture lacks the Valid Login status")
+	}
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name, "output.xml")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	return write("base", data), write("head", bytes.Replace(data, []byte(pass), []byte(fail), 1))
+}
+
+func TestRunDiffCommand(t *testing.T) {
+	base, head := diffFixtures(t)
+	missing := filepath.Join(t.TempDir(), "output.xml")
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		// stdout and stderr hold text expected in each output.
+		stdout, stderr []string
+		// check inspects the output further.
+		check func(t *testing.T, stdout string)
+	}{
+		{
+			name:   "text",
+			args:   []string{"--changed-only", base, head},
+			code:   1,
+			stdout: []string{"Fixture Run.Login.Valid Login  PASS  FAIL  Different statuses", "1 regression(s):\n- Fixture Run.Login.Valid Login (base -> head)"},
+		},
+		{
+			name:   "markdown",
+			args:   []string{"--format", "markdown", "--changed-only", "--title", "Nightly", base, head},
+			code:   1,
+			stdout: []string{"# Nightly", "## Fixture Run.Login", "| Valid Login | PASS | **FAIL** | Different statuses |"},
+		},
+		{
+			name: "json",
+			args: []string{"--format", "json", "--names", "before, after", base, head},
+			code: 1,
+			check: func(t *testing.T, stdout string) {
+				var report struct {
+					Columns []string `json:"columns"`
+					Suites  []struct {
+						Suites []struct {
+							Name  string `json:"name"`
+							Tests []struct {
+								Name    string   `json:"name"`
+								Status  string   `json:"status"`
+								Results []string `json:"results"`
+							} `json:"tests"`
+						} `json:"suites"`
+					} `json:"suites"`
+				}
+				if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+					t.Fatalf("invalid JSON: %v", err)
+				}
+				if want := []string{"before", "after"}; !reflect.DeepEqual(report.Columns, want) {
+					t.Errorf("columns %v, want %v", report.Columns, want)
+				}
+				found := false
+				for _, root := range report.Suites {
+					for _, suite := range root.Suites {
+						for _, test := range suite.Tests {
+							if suite.Name != "Login" || test.Name != "Valid Login" {
+								continue
+							}
+							found = true
+							if test.Status != "diff" || !reflect.DeepEqual(test.Results, []string{"PASS", "FAIL"}) {
+								t.Errorf("Valid Login: %s %v, want diff [PASS FAIL]", test.Status, test.Results)
+							}
+						}
+					}
+				}
+				if !found {
+					t.Error("no Login.Valid Login test in the report")
+				}
+			},
+		},
+		{
+			name:   "fixed test is not a regression",
+			args:   []string{"--changed-only", head, base},
+			code:   0,
+			stdout: []string{"Fixture Run.Login.Valid Login  FAIL  PASS  Different statuses", "No regressions."},
+		},
+		{
+			name:   "one file",
+			args:   []string{base},
+			code:   2,
+			stderr: []string{"expected at least two output files"},
+		},
+		{
+			name:   "unknown format",
+			args:   []string{"--format", "html", base, head},
+			code:   2,
+			stderr: []string{`unknown format "html"`},
+		},
+		{
+			name:   "names count mismatch",
+			args:   []string{"--names", "a,b,c", base, head},
+			code:   2,
+			stderr: []string{"--names has 3 entries but 2 files were given"},
+		},
+		{
+			name:   "missing file",
+			args:   []string{base, missing},
+			code:   2,
+			stderr: []string{"Error: parse " + missing},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runDiffCommand(tt.args, &stdout, &stderr); code != tt.code {
+				t.Errorf("exit code %d, want %d; stderr:\n%s", code, tt.code, stderr.String())
+			}
+			for _, want := range tt.stdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout lacks %q:\n%s", want, stdout.String())
+				}
+			}
+			for _, want := range tt.stderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr lacks %q:\n%s", want, stderr.String())
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	robodiff "robot_diff/backend/diff"
+)
+
+const mergeUsage = `robodiff merge: combine Robot Framework outputs like 'rebot --merge'
+
+Usage:
+	robodiff merge [options] <output.xml> <rerun.xml> [<rerun.xml>...]
+
+Reads the given outputs (any format 'robodiff diff' accepts) and writes one
+output.xml. Suites are matched by name; a test that appears in a later file
+replaces the earlier result, and its message records the old and new status.
+A later result that was skipped does not replace one that ran. Statistics are
+recomputed. Exits with status 2 on usage, parse or write errors and 0
+otherwise.
+
+Options:
+	-o, --output path  Where to write the merged output. Default: stdout.
+	--name name        Name of the top-level suite. Default: from the inputs.
+	-h, --help         Print this usage instruction.
+
+Examples:
+	robodiff merge -o merged.xml output.xml rerun.xml
+	robodiff merge pabot_results/*/output.xml > output.xml
+`
+
+type mergeConfig struct {
+	Help   bool
+	Output string
+	Name   string
+}
+
+func runMergeCommand(args []string, stdout, stderr io.Writer) int {
+	config := &mergeConfig{}
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&config.Help, "h", false, "Show help")
+	fs.BoolVar(&config.Help, "help", false, "Show help")
+	fs.StringVar(&config.Output, "o", "", "Output file")
+	fs.StringVar(&config.Output, "output", "", "Output file")
+	fs.StringVar(&config.Name, "name", "", "Top-level suite name")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, mergeUsage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if config.Help {
+		fmt.Fprint(stdout, mergeUsage)
+		return 0
+	}
+
+	files := fs.Args()
+	if len(files) < 1 {
+		fmt.Fprintln(stderr, "Error: expected at least one output file")
+		fmt.Fprint(stderr, mergeUsage)
+		return 2
+	}
+
+	robots := make([]*robodiff.Robot, len(files))
+	for i, file := range files {
+		robot, err := robodiff.ParseResultFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: parse %s: %v\n", file, err)
+			return 2
+		}
+		robots[i] = robot
+	}
+	merged := robodiff.MergeRobotsWithOptions(robodiff.MergeOptions{Notes: true}, robots...)
+	if config.Name != "" {
+		merged.Suite.Name = config.Name
+	}
+
+	if config.Output == "" || config.Output == "-" {
+		if err := robodiff.WriteRobotXML(stdout, merged); err != nil {
+			fmt.Fprintf(stderr, "Error: write output: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	if err := writeFileAtomic(config.Output, func(w io.Writer) error {
+		return robodiff.WriteRobotXML(w, merged)
+	}); err != nil {
+		fmt.Fprintf(stderr, "Error: write %s: %v\n", config.Output, err)
+		return 2
+	}
+	return 0
+}
+
+// writeFileAtomic writes path through a temporary file in the same directory
+// so an input can safely be overwritten.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
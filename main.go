@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
+	robodiff "robot_diff/backend/diff"
 	backend "robot_diff/backend/server"
 	"robot_diff/backend/store"
 )
@@ -16,15 +18,47 @@ const usage = `robodiff: local server + React UI for Robot Framework outputs
 
 Usage:
 	robodiff [options] [<results-dir>]
+	robodiff diff [options] <output.xml> <output.xml> [<output.xml>...]
+	robodiff merge [options] <output.xml> <rerun.xml> [<rerun.xml>...]
 
 Starts a local HTTP server and scans a directory for Robot Framework output files
-(typically named 'output.xml'). If <results-dir> is omitted, the current directory
-is used.
+(typically named 'output.xml', or 'output.json' for Robot Framework 7 JSON
+results) and JUnit/xUnit XML files, also inside .gz files and .zip/.tar(.gz)
+archives. If <results-dir> is omitted, the current directory is used.
+
+The 'diff' subcommand compares output files on the command line and exits
+without starting the server. Run 'robodiff diff --help' for its options.
+The 'merge' subcommand combines outputs into one output.xml like
+'rebot --merge'; see 'robodiff merge --help'.
 
 Options:
 	--dir path               Directory to scan for Robot outputs (alternative to positional arg).
 	--addr addr              HTTP listen address. Default: ':8080'.
 	--scan-interval duration Directory scan interval. Default: 2s.
+	--watch                  React to file system events (inotify, Linux only) instead
+	                         of rescanning every --scan-interval. Falls back to
+	                         polling when watching is unavailable.
+	--reconcile-interval d   Full rescan interval in --watch mode. Default: 5m.
+	--merge-pattern globs    Comma-separated globs (relative to a directory) of outputs
+	                         that make up one run, e.g. 'output*.xml,pabot_results/*/output.xml'.
+	                         A directory where they match two or more files is listed
+	                         as a single run merged like 'rebot --merge'. A directory
+	                         with a '.robodiff-merge' file is always merged.
+	--label-metadata keys    Comma-separated top-level suite metadata names whose values
+	                         become run labels, as 'label=Metadata Name' or just
+	                         'Name' (label 'name'), e.g. 'branch=Branch,env=Environment'.
+	--label-pattern regex    Regular expression matched against each run's relative
+	                         path; named groups become run labels, e.g.
+	                         '^(?P<branch>[^/]+)/(?P<env>[^/]+)/'.
+	--max-keyword-depth n    Keep at most n keyword levels per test in memory; deeper
+	                         levels are re-read from disk when a test is opened.
+	                         Default: 0 (keep all).
+	--drop-passing-bodies    Do not keep keyword bodies of passing tests in memory.
+	--index-mode             Keep only an index of tests (name, status, byte range)
+	                         and read keyword bodies from disk when a test is opened.
+	--max-loaded-runs n      Keep at most n parsed runs in memory; least recently
+	                         used runs are re-parsed on demand. Default: 0 (no limit).
+	--max-parsed-mb n        Keep parsed runs within roughly n MB. Default: 0 (no limit).
 	-h, --help               Print this usage instruction.
 
 Examples:
@@ -32,16 +66,34 @@ Examples:
 	robodiff .
 	robodiff --addr :3000 /path/to/results
 	robodiff --dir /path/to/results
+	robodiff diff baseline/output.xml candidate/output.xml
 `
 
 type Config struct {
-	Help         bool
-	Dir          string
-	Addr         string
-	ScanInterval time.Duration
+	Help              bool
+	Dir               string
+	Addr              string
+	ScanInterval      time.Duration
+	MaxKeywordDepth   int
+	DropPassingBodies bool
+	IndexMode         bool
+	MaxLoadedRuns     int
+	MaxParsedMB       int
+	Watch             bool
+	ReconcileInterval time.Duration
+	MergePatterns     string
+	LabelMetadata     string
+	LabelPattern      string
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiffCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "merge" {
+		os.Exit(runMergeCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	config := parseArgs()
 
 	if config.Help {
@@ -71,7 +123,30 @@ func main() {
 		config.ScanInterval = 2 * time.Second
 	}
 
-	runStore := store.NewRunStore(dir, config.ScanInterval)
+	var labelPattern *regexp.Regexp
+	if config.LabelPattern != "" {
+		re, err := regexp.Compile(config.LabelPattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --label-pattern: %v\n", err)
+			os.Exit(1)
+		}
+		labelPattern = re
+	}
+
+	runStore := store.NewRunStore(dir, config.ScanInterval, store.Options{
+		Parse: robodiff.ParseOptions{
+			MaxKeywordDepth:   config.MaxKeywordDepth,
+			DropPassingBodies: config.DropPassingBodies,
+			IndexOnly:         config.IndexMode,
+		},
+		MaxLoadedRuns:     config.MaxLoadedRuns,
+		MaxParsedBytes:    int64(config.MaxParsedMB) * 1024 * 1024,
+		Watch:             config.Watch,
+		ReconcileInterval: config.ReconcileInterval,
+		MergePatterns:     splitList(config.MergePatterns),
+		LabelMetadata:     store.ParseLabelMetadata(splitList(config.LabelMetadata)),
+		LabelPattern:      labelPattern,
+	})
 	runStore.Start()
 	server := backend.NewServer(config.Addr, runStore)
 	fmt.Printf("Serving on http://localhost%s (watching %s)\n", normalizeLocalhostAddr(config.Addr), dir)
@@ -89,6 +164,16 @@ func parseArgs() *Config {
 	flag.StringVar(&config.Dir, "dir", "", "Directory to scan for Robot XML outputs")
 	flag.StringVar(&config.Addr, "addr", ":8080", "HTTP listen address")
 	flag.DurationVar(&config.ScanInterval, "scan-interval", 2*time.Second, "Directory scan interval")
+	flag.IntVar(&config.MaxKeywordDepth, "max-keyword-depth", 0, "Keyword levels to keep per test (0 = all)")
+	flag.BoolVar(&config.DropPassingBodies, "drop-passing-bodies", false, "Drop keyword bodies of passing tests")
+	flag.BoolVar(&config.IndexMode, "index-mode", false, "Index tests by byte offset and load bodies on demand")
+	flag.IntVar(&config.MaxLoadedRuns, "max-loaded-runs", 0, "Maximum parsed runs kept in memory (0 = no limit)")
+	flag.IntVar(&config.MaxParsedMB, "max-parsed-mb", 0, "Approximate memory budget for parsed runs in MB (0 = no limit)")
+	flag.BoolVar(&config.Watch, "watch", false, "Watch the directory for changes instead of polling")
+	flag.DurationVar(&config.ReconcileInterval, "reconcile-interval", store.DefaultReconcileInterval, "Full rescan interval in watch mode")
+	flag.StringVar(&config.MergePatterns, "merge-pattern", "", "Comma-separated globs of outputs merged into one run")
+	flag.StringVar(&config.LabelMetadata, "label-metadata", "", "Comma-separated suite metadata names used as run labels")
+	flag.StringVar(&config.LabelPattern, "label-pattern", "", "Regexp whose named groups on the relative path become run labels")
 
 	flag.Usage = func() {
 		fmt.Print(usage)
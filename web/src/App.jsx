@@ -7,6 +7,7 @@ import RunList from "./components/RunList";
 import SingleRunView from "./components/SingleRunView";
 import DiffView from "./components/DiffView";
 import { buildApiUrl } from "./utils/apiBase";
+import { flattenDiffSuites } from "./utils/diffSuites";
 
 function calculateTestStatus(results = []) {
   const list = Array.isArray(results) ? results : [];
@@ -19,6 +20,31 @@ function calculateTestStatus(results = []) {
   return "all_failed";
 }
 
+// matchesRunSearch checks a run against the run list search. "key=value"
+// terms must match a run label; the rest of the query is matched against the
+// run's name and path.
+function matchesRunSearch(run, query) {
+  const terms = query.trim().split(/\s+/).filter(Boolean);
+  const labelTerms = terms.filter((term) => term.includes("="));
+  for (const term of labelTerms) {
+    const [key, ...rest] = term.split("=");
+    const value = rest.join("=").toLowerCase();
+    const actual = run.labels?.[key];
+    if (actual === undefined || String(actual).toLowerCase() !== value) {
+      return false;
+    }
+  }
+  const text = terms
+    .filter((term) => !term.includes("="))
+    .join(" ")
+    .toLowerCase();
+  if (!text) return true;
+  return (
+    run.name.toLowerCase().includes(text) ||
+    run.relPath.toLowerCase().includes(text)
+  );
+}
+
 function App() {
   const [runs, setRuns] = useState([]);
   const [dir, setDir] = useState("");
@@ -36,6 +62,7 @@ function App() {
   const [sortBy, setSortBy] = useState("modTime");
   const [sortDir, setSortDir] = useState("desc");
   const [diffFilter, setDiffFilter] = useState("all");
+  const [diffAttempt, setDiffAttempt] = useState("final");
   const [collapsedSuites, setCollapsedSuites] = useState(() => new Set());
   const [showHelp, setShowHelp] = useState(false);
   const [showRunList, setShowRunList] = useState(true);
@@ -70,12 +97,7 @@ function App() {
 
   // Filtered and sorted runs
   const filteredRuns = useMemo(() => {
-    let filtered = runs.filter((r) =>
-      searchQuery
-        ? r.name.toLowerCase().includes(searchQuery.toLowerCase()) ||
-          r.relPath.toLowerCase().includes(searchQuery.toLowerCase())
-        : true,
-    );
+    let filtered = runs.filter((r) => matchesRunSearch(r, searchQuery));
 
     filtered.sort((a, b) => {
       const aPinned = pinned.has(a.id);
@@ -104,15 +126,20 @@ function App() {
   // Filtered diff suites
   const filteredDiffSuites = useMemo(() => {
     if (!diff?.suites) return [];
-    return diff.suites
+    return flattenDiffSuites(diff.suites)
       .map((suite) => {
         let tests = suite.tests;
         if (diffFilter === "failures") {
           tests = tests.filter((t) => t.results.includes("FAIL"));
         } else if (diffFilter === "diffs") {
           tests = tests.filter((t) => {
-            const st = calculateTestStatus(t.results || []);
-            return st === "diff" || st === "missing";
+            const st = t.status || calculateTestStatus(t.results || []);
+            return (
+              st === "diff" ||
+              st === "missing" ||
+              st === "failed_differently" ||
+              st.includes("_to_")
+            );
           });
         }
         return { ...suite, tests };
@@ -238,7 +265,7 @@ function App() {
     }
   }
 
-  async function generateDiff(idsOrEvent) {
+  async function generateDiff(idsOrEvent, attempt = diffAttempt) {
     const ids = Array.isArray(idsOrEvent) ? idsOrEvent : selectedIds;
     if (!ids || ids.length < 1) return;
     setLoadingDiff(true);
@@ -258,7 +285,7 @@ function App() {
       const res = await fetch(buildApiUrl("/api/diff"), {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ runIds: ids, title }),
+        body: JSON.stringify({ runIds: ids, title, attempt }),
       });
       const data = await res.json().catch(() => ({}));
       if (!res.ok) {
@@ -380,8 +407,34 @@ function App() {
 
   useEffect(() => {
     refreshRuns();
-    const t = setInterval(refreshRuns, 2000);
-    return () => clearInterval(t);
+    let timer = null;
+    const startPolling = () => {
+      if (!timer) timer = setInterval(refreshRuns, 2000);
+    };
+    if (typeof EventSource === "undefined") {
+      startPolling();
+      return () => clearInterval(timer);
+    }
+
+    // Prefer server push; fall back to polling if the stream fails.
+    const events = new EventSource(buildApiUrl("/api/events"));
+    const onChange = () => refreshRuns();
+    for (const type of [
+      "run-added",
+      "run-removed",
+      "run-updated",
+      "hydration-finished",
+    ]) {
+      events.addEventListener(type, onChange);
+    }
+    events.onerror = () => {
+      events.close();
+      startPolling();
+    };
+    return () => {
+      events.close();
+      clearInterval(timer);
+    };
   }, []);
 
   // Keyboard shortcuts
@@ -558,6 +611,14 @@ function App() {
           collapsedSuites={collapsedSuites}
           onToggleSuite={toggleSuite}
           runIds={selectedIds}
+          hasMergedRuns={runs.some(
+            (run) => selected.has(run.id) && run.parts > 0,
+          )}
+          attempt={diffAttempt}
+          onAttemptChange={(attempt) => {
+            setDiffAttempt(attempt);
+            generateDiff(selectedIds, attempt);
+          }}
         />
       )}
 
@@ -1,5 +1,6 @@
 import { useState } from "react";
 import TestComparisonModal from "./TestComparisonModal";
+import { flattenDiffSuites } from "../utils/diffSuites";
 
 function calculateTestStatus(results) {
   const hasPass = results.includes("PASS");
@@ -21,7 +22,17 @@ function statusLabel(status) {
       return "PASS";
     case "all_failed":
       return "FAIL";
+    case "failed_differently":
+      return "FAIL≠";
+    case "all_skipped":
+      return "SKIP";
     default:
+      if (status.includes("_to_")) {
+        return status
+          .split("_to_")
+          .map((s) => s.replace("_", " ").toUpperCase())
+          .join("→");
+      }
       return status;
   }
 }
@@ -29,6 +40,7 @@ function statusLabel(status) {
 function statusClass(status) {
   switch (status) {
     case "diff":
+    case "failed_differently":
       return "status-diff";
     case "missing":
       return "status-missing";
@@ -36,11 +48,41 @@ function statusClass(status) {
       return "status-pass";
     case "all_failed":
       return "status-fail";
+    case "all_skipped":
+      return "status-skip";
     default:
-      return "";
+      return status.includes("_to_") ? "status-diff" : "";
   }
 }
 
+// Collapse keys of the blocks above the suites; suite names never start
+// with NUL.
+const NEW_ERRORS_KEY = "\u0000errors";
+const TAG_SUMMARY_KEY = "\u0000tags";
+
+function sameCounts(a, b) {
+  if (!a || !b) return a === b;
+  return a.pass === b.pass && a.fail === b.fail && a.skip === b.skip;
+}
+
+// tagChange describes how a tag's counts move across runs, e.g.
+// "40→37 passing, 0→3 failing".
+function tagChange(counts) {
+  return [
+    ["pass", "passing"],
+    ["fail", "failing"],
+    ["skip", "skipped"],
+  ]
+    .map(([field, label]) => {
+      const values = counts.map((c) => (c ? String(c[field]) : "-"));
+      return values.some((v) => v !== values[0])
+        ? `${values.join("→")} ${label}`
+        : null;
+    })
+    .filter(Boolean)
+    .join(", ");
+}
+
 export default function DiffView({
   diff,
   onClose,
@@ -50,13 +92,24 @@ export default function DiffView({
   collapsedSuites,
   onToggleSuite,
   runIds,
+  hasMergedRuns,
+  attempt,
+  onAttemptChange,
 }) {
   const [comparisonTest, setComparisonTest] = useState(null);
   const [copyStatus, setCopyStatus] = useState("");
+  // Execution errors of each run that the run before it did not have.
+  const newErrors = (Array.isArray(diff?.errors) ? diff.errors : []).filter(
+    (column) => column.new?.length > 0,
+  );
+  const tagSummary = (Array.isArray(diff?.tags) ? diff.tags : []).filter(
+    (tag) =>
+      diffFilter !== "diffs" ||
+      tag.counts.some((c) => !sameCounts(c, tag.counts[0])),
+  );
 
   const handleCopyDifferingTests = async () => {
-    const suites = Array.isArray(diff?.suites) ? diff.suites : [];
-    const lines = suites.flatMap((suite) =>
+    const lines = flattenDiffSuites(diff?.suites).flatMap((suite) =>
       (suite.tests || [])
         .filter((test) => {
           const results = test.results || [];
@@ -98,6 +151,16 @@ export default function DiffView({
               ) : null}
             </>
           ) : null}
+          {hasMergedRuns ? (
+            <select
+              value={attempt}
+              onChange={(e) => onAttemptChange(e.target.value)}
+              title="Status of re-executed tests in merged runs"
+            >
+              <option value="final">Final status</option>
+              <option value="first">First attempt</option>
+            </select>
+          ) : null}
           <button
             className={diffFilter === "all" ? "active" : ""}
             onClick={() => onFilterChange("all")}
@@ -122,18 +185,129 @@ export default function DiffView({
         </button>
       </div>
 
+      {newErrors.length > 0 ? (
+        <div className="suite execution-errors">
+          <h3
+            className="suite-header"
+            onClick={() => onToggleSuite(NEW_ERRORS_KEY)}
+          >
+            <span className="collapse-icon">
+              {collapsedSuites.has(NEW_ERRORS_KEY) ? "▶" : "▼"}
+            </span>
+            New Execution Errors
+            <span className="suite-count">
+              ({newErrors.reduce((n, c) => n + c.new.length, 0)} messages)
+            </span>
+          </h3>
+          {!collapsedSuites.has(NEW_ERRORS_KEY) && (
+            <div className="table-wrapper">
+              <table className="diff">
+                <thead>
+                  <tr>
+                    <th style={{ width: "160px" }}>Run</th>
+                    <th style={{ width: "80px" }}>Level</th>
+                    <th>Message</th>
+                  </tr>
+                </thead>
+                <tbody>
+                  {newErrors.flatMap((column) =>
+                    column.new.map((e, i) => (
+                      <tr key={`${column.column}-${i}`}>
+                        <td>{column.column}</td>
+                        <td>
+                          <span
+                            className={`status ${
+                              e.level === "WARN" ? "status-skip" : "status-fail"
+                            }`}
+                          >
+                            {e.level}
+                          </span>
+                        </td>
+                        <td className="test-name execution-error-message">
+                          {e.message}
+                        </td>
+                      </tr>
+                    )),
+                  )}
+                </tbody>
+              </table>
+            </div>
+          )}
+        </div>
+      ) : null}
+
+      {tagSummary.length > 0 ? (
+        <div className="suite tag-summary">
+          <h3
+            className="suite-header"
+            onClick={() => onToggleSuite(TAG_SUMMARY_KEY)}
+          >
+            <span className="collapse-icon">
+              {collapsedSuites.has(TAG_SUMMARY_KEY) ? "▶" : "▼"}
+            </span>
+            Tag Summary
+            <span className="suite-count">({tagSummary.length} tags)</span>
+          </h3>
+          {!collapsedSuites.has(TAG_SUMMARY_KEY) && (
+            <div className="table-wrapper">
+              <table className="diff">
+                <thead>
+                  <tr>
+                    <th>Tag</th>
+                    {(diff.columns || []).map((c) => (
+                      <th key={c} title="Pass / fail / skip">
+                        {c}
+                      </th>
+                    ))}
+                    <th>Change</th>
+                  </tr>
+                </thead>
+                <tbody>
+                  {tagSummary.map((tag) => (
+                    <tr key={tag.tag}>
+                      <td className="test-name">{tag.tag}</td>
+                      {tag.counts.map((c, i) => (
+                        <td key={i} className="tag-counts">
+                          {c ? (
+                            <>
+                              <span className="status-pass">{c.pass}</span>
+                              {" / "}
+                              <span className="status-fail">{c.fail}</span>
+                              {" / "}
+                              <span className="status-skip">{c.skip}</span>
+                            </>
+                          ) : (
+                            <span className="status status-missing">
+                              MISSING
+                            </span>
+                          )}
+                        </td>
+                      ))}
+                      <td className="muted">{tagChange(tag.counts)}</td>
+                    </tr>
+                  ))}
+                </tbody>
+              </table>
+            </div>
+          )}
+        </div>
+      ) : null}
+
       {filteredDiffSuites.map((suite) => {
-        const displaySuiteName = suite.name.split(".").pop() || suite.name;
-        const isCollapsed = collapsedSuites.has(suite.name);
+        const isCollapsed = collapsedSuites.has(suite.key);
         return (
-          <div className="suite" key={suite.name}>
+          <div
+            className="suite"
+            key={suite.key}
+            style={{ marginLeft: `${Math.max(suite.depth - 1, 0) * 16}px` }}
+          >
             <h3
               className="suite-header"
-              onClick={() => onToggleSuite(suite.name)}
-              title={suite.name}
+              onClick={() => onToggleSuite(suite.key)}
+              title={suite.longName}
             >
               <span className="collapse-icon">{isCollapsed ? "▶" : "▼"}</span>
-              {displaySuiteName}
+              {suite.name}
               <span className="suite-count">({suite.tests.length} tests)</span>
             </h3>
             {!isCollapsed && (
@@ -150,23 +324,35 @@ export default function DiffView({
                   </thead>
                   <tbody>
                     {suite.tests.map((t) => {
-                      const st = calculateTestStatus(t.results || []);
+                      const st =
+                        t.status || calculateTestStatus(t.results || []);
                       return (
                         <tr
                           key={t.name}
                           className={`test-row ${st}`}
                           onClick={() =>
                             setComparisonTest({
-                              suiteName: suite.name,
+                              suiteName: suite.longName,
                               testName: t.name,
-                              fullName: `${suite.name}.${t.name}`,
+                              fullName: `${suite.longName}.${t.name}`,
                               results: t.results || [],
+                              columnNames: t.columnNames,
                             })
                           }
                           style={{ cursor: "pointer" }}
                           title="Click to compare test details"
                         >
-                          <td className="test-name">{t.name}</td>
+                          <td className="test-name">
+                            {t.name}
+                            {t.renamedFrom && (
+                              <div
+                                className="renamed-from"
+                                title={`Matched by ${t.matchedBy || "name"}`}
+                              >
+                                renamed from {t.renamedFrom}
+                              </div>
+                            )}
+                          </td>
                           {(t.results || []).map((v, i) => (
                             <td
                               key={i}
@@ -178,13 +364,17 @@ export default function DiffView({
                                     ? "status-missing"
                                     : `status-${v.toLowerCase()}`
                                 }`}
+                                title={(t.messages || [])[i] || undefined}
                               >
                                 {v}
                               </span>
                             </td>
                           ))}
                           <td>
-                            <span className={`status ${statusClass(st)}`}>
+                            <span
+                              className={`status ${statusClass(st)}`}
+                              title={t.explanation || undefined}
+                            >
                               {statusLabel(st)}
                             </span>
                           </td>
@@ -205,6 +395,7 @@ export default function DiffView({
           testName={comparisonTest.testName}
           fullName={comparisonTest.fullName}
           results={comparisonTest.results}
+          columnNames={comparisonTest.columnNames}
           runIds={runIds || []}
           runNames={diff.columns || []}
           onClose={() => setComparisonTest(null)}
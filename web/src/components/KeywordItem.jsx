@@ -36,6 +36,15 @@ export default function KeywordItem({ keyword, depth, runId }) {
   const hasMessages = keyword.messages && keyword.messages.length > 0;
   const hasArguments = keyword.arguments && keyword.arguments.length > 0;
   const hasFail = keyword.status?.toLowerCase() === "fail";
+  const hasMeta = Boolean(
+    keyword.doc ||
+      keyword.tags?.length > 0 ||
+      keyword.timeout ||
+      keyword.source,
+  );
+  const location = keyword.source
+    ? `${keyword.source}${keyword.line ? `:${keyword.line}` : ""}`
+    : "";
 
   const apiRequestCount = countHttpRequestMessagesInBranch(keyword);
 
@@ -50,7 +59,7 @@ export default function KeywordItem({ keyword, depth, runId }) {
   const defaultCollapsed = depth === 0 ? !hasFailInBranchForExpand : !hasFail;
   const [isCollapsed, setIsCollapsed] = useState(defaultCollapsed);
 
-  const hasContent = hasArguments || hasMessages || hasChildren;
+  const hasContent = hasArguments || hasMessages || hasChildren || hasMeta;
 
   // Determine effective status: bubble failures from children unless this keyword
   // is a boundary (e.g., Run Keyword And Return Status).
@@ -176,7 +185,27 @@ export default function KeywordItem({ keyword, depth, runId }) {
         </span>
         <span className="keyword-type">{keyword.type}</span>
         <span className="keyword-header-main">
-          <span className="keyword-name">
+          <span
+            className="keyword-name"
+            title={
+              [
+                keyword.sourceName && keyword.sourceName !== keyword.name
+                  ? `Defined as: ${keyword.sourceName}`
+                  : "",
+                location,
+              ]
+                .filter(Boolean)
+                .join("\n") || undefined
+            }
+          >
+            {keyword.assign?.length > 0 ? (
+              <span className="keyword-assign">
+                {`${keyword.assign.join("    ")} = `}
+              </span>
+            ) : null}
+            {keyword.owner ? (
+              <span className="keyword-owner">{`${keyword.owner}.`}</span>
+            ) : null}
             {keyword.name}
             {apiRequestCount > 0 ? (
               <span className="api-pill">
@@ -242,6 +271,40 @@ export default function KeywordItem({ keyword, depth, runId }) {
 
       {!isCollapsed && (
         <>
+          {hasMeta && (
+            <div className="keyword-meta">
+              {keyword.doc ? (
+                <div className="keyword-doc muted">{keyword.doc}</div>
+              ) : null}
+              {keyword.tags?.length > 0 || keyword.timeout || location ? (
+                <div className="keyword-meta-row">
+                  {keyword.tags?.map((tag) => (
+                    <span key={tag} className="keyword-tag">
+                      {tag}
+                    </span>
+                  ))}
+                  {keyword.timeout ? (
+                    <span className="muted">{`Timeout: ${keyword.timeout}`}</span>
+                  ) : null}
+                  {location ? (
+                    <button
+                      type="button"
+                      className="json-copy-btn keyword-source"
+                      title="Copy source location"
+                      onClick={(e) => {
+                        e.preventDefault();
+                        e.stopPropagation();
+                        copyToClipboard(location);
+                      }}
+                    >
+                      {location}
+                    </button>
+                  ) : null}
+                </div>
+              ) : null}
+            </div>
+          )}
+
           {hasArguments && (
             <div className="keyword-arguments">{renderedArguments}</div>
           )}
@@ -58,7 +58,40 @@ function normalizeScreenshotPath(path) {
   return cleaned.slice(idx);
 }
 
+// withApiBase points the /api/ URLs in sanitized HTML messages (screenshots
+// served as artifacts or run files) at the API server.
+function withApiBase(html) {
+  return html.replace(/(src|href)="\/api\//g, (_, attr) => {
+    return `${attr}="${buildApiUrl("/api/")}`;
+  });
+}
+
 export default function MessageItem({ message, runId }) {
+  if (message?.html) {
+    // The server sanitizes HTML messages before sending them.
+    return (
+      <div
+        className={`message-item message-${
+          message.level?.toLowerCase() || "info"
+        }`}
+      >
+        <div className="message-meta">
+          <span className="message-level">{message.level}</span>
+          {message.timestamp && (
+            <span className="message-timestamp">
+              {formatTime(message.timestamp)}
+            </span>
+          )}
+        </div>
+        <div
+          className="message-text message-html"
+          dangerouslySetInnerHTML={{ __html: withApiBase(message.text || "") }}
+        />
+      </div>
+    );
+  }
+
+
   const comparison = tryExtractJsonishComparison(message.text);
   const isFailLevel =
     String(message.level || "").toLowerCase() === "fail" ||
@@ -75,9 +108,7 @@ export default function MessageItem({ message, runId }) {
   const firstUrlCopyIndex = segments.findIndex(
     (s) => s?.type === "copy" && isUrlKey(s.key),
   );
-  const screenshotPath = message?.html
-    ? extractScreenshotPath(message.text)
-    : extractScreenshotPath(message.text);
+  const screenshotPath = extractScreenshotPath(message.text);
   const normalizedScreenshotPath = normalizeScreenshotPath(screenshotPath);
   const screenshotUrl =
     runId && normalizedScreenshotPath
@@ -35,6 +35,12 @@ function formatBytes(bytes) {
   return `${value.toFixed(precision)} ${units[idx]}`;
 }
 
+// Robot XML is the default and gets no badge.
+const FORMAT_LABELS = {
+  "robot-json": "JSON",
+  junit: "xUnit",
+};
+
 export default function RunList({
   runs,
   dir,
@@ -133,7 +139,7 @@ export default function RunList({
           <div className="search-box">
             <input
               type="search"
-              placeholder="Search runs... (Ctrl+F)"
+              placeholder="Search runs or key=value labels... (Ctrl+F)"
               value={searchQuery}
               onChange={(e) => onSearchChange(e.target.value)}
             />
@@ -319,6 +325,20 @@ export default function RunList({
                     </span>
                   )}
                 </th>
+                <th
+                  className={`sortable ${
+                    sortBy === "skipCount" ? "sort-active" : ""
+                  }`}
+                  onClick={() => onSort("skipCount")}
+                  style={{ width: "80px" }}
+                >
+                  Skip
+                  {sortBy === "skipCount" && (
+                    <span className="sort-arrow">
+                      {sortDir === "asc" ? "↑" : "↓"}
+                    </span>
+                  )}
+                </th>
                 <th>Pass Rate</th>
                 <th
                   className={`sortable ${
@@ -435,6 +455,45 @@ export default function RunList({
                         ) : (
                           <>
                             <span>{run.name}</span>
+                            {run.parts > 0 && (
+                              <span
+                                className="badge format-badge"
+                                title={`Merged from ${run.parts} output file(s)`}
+                              >
+                                {`merged ×${run.parts}`}
+                              </span>
+                            )}
+                            {FORMAT_LABELS[run.format] && (
+                              <span
+                                className="badge format-badge"
+                                title={`Result format: ${run.format}`}
+                              >
+                                {FORMAT_LABELS[run.format]}
+                              </span>
+                            )}
+                            {(run.errorCount > 0 || run.warningCount > 0) && (
+                              <span
+                                className={`badge ${
+                                  run.errorCount > 0 ? "fail-badge" : "warn-badge"
+                                } errors-badge`}
+                                title={`Execution errors: ${run.errorCount}, warnings: ${run.warningCount}`}
+                              >
+                                {run.errorCount > 0
+                                  ? `${run.errorCount} error(s)`
+                                  : `${run.warningCount} warning(s)`}
+                              </span>
+                            )}
+                            {Object.entries(run.labels || {}).map(
+                              ([key, value]) => (
+                                <span
+                                  key={key}
+                                  className="badge label-badge"
+                                  title={`Label ${key}: search "${key}=${value}" to filter`}
+                                >
+                                  {`${key}=${value}`}
+                                </span>
+                              ),
+                            )}
                             <button
                               type="button"
                               className="rename-btn"
@@ -459,6 +518,9 @@ export default function RunList({
                     <td className="num-cell">{run.testCount}</td>
                     <td className="num-cell pass-cell">{run.passCount}</td>
                     <td className="num-cell fail-cell">{run.failCount}</td>
+                    <td className="num-cell skip-cell">
+                      {run.skipCount || 0}
+                    </td>
                     <td>
                       <div className="progress-bar">
                         <div
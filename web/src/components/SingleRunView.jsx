@@ -1,9 +1,13 @@
 import { useLayoutEffect, useRef, useState } from "react";
 import Sidebar from "./Sidebar";
 import TestDetailsPanel from "./TestDetailsPanel";
+import MessageItem from "./MessageItem";
 import TimeBreakdown from "./TimeBreakdown";
 import { buildApiUrl } from "../utils/apiBase";
 
+// Collapse key of the execution errors block; suite names never start with NUL.
+const EXECUTION_ERRORS_KEY = "\u0000errors";
+
 export default function SingleRunView({
   singleRun,
   onClose,
@@ -69,6 +73,26 @@ export default function SingleRunView({
     }
   };
 
+  // Suite setups and teardowns come with /api/run, so they open in the details
+  // panel without another request.
+  const handleFixtureClick = (suite, kind) => {
+    const fixture = suite[kind];
+    const label = kind === "setup" ? "Suite Setup" : "Suite Teardown";
+    if (mainContentRef.current) {
+      mainScrollTopRef.current = mainContentRef.current.scrollTop;
+    }
+    setActiveSuite(suite.name);
+    setActiveTestName(null);
+    setTestDetails({
+      runId: singleRun.runId,
+      name: `${suite.name} — ${label}`,
+      status: fixture.status,
+      start: fixture.start,
+      end: fixture.end,
+      keywords: [fixture],
+    });
+  };
+
   const handleCopyFailedTests = async () => {
     const failedTests = (singleRun?.suites || []).flatMap((suite) =>
       (suite.tests || [])
@@ -182,6 +206,34 @@ export default function SingleRunView({
           />
         ) : null}
 
+        {mode === "tests" && singleRun.errors?.length > 0 ? (
+          <div className="suite execution-errors">
+            <h3
+              className="suite-header"
+              onClick={() => onToggleSuite(EXECUTION_ERRORS_KEY)}
+            >
+              <span className="suite-toggle">
+                {collapsedSuites.has(EXECUTION_ERRORS_KEY) ? "▶" : "▼"}
+              </span>
+              Test Execution Errors
+              <span className="suite-count muted">
+                ({singleRun.errors.length} messages)
+              </span>
+            </h3>
+            {!collapsedSuites.has(EXECUTION_ERRORS_KEY) && (
+              <div className="suite-content">
+                {singleRun.errors.map((message, i) => (
+                  <MessageItem
+                    key={i}
+                    message={message}
+                    runId={singleRun.runId}
+                  />
+                ))}
+              </div>
+            )}
+          </div>
+        ) : null}
+
         {mode === "tests"
           ? singleRun.suites?.map((suite) => {
           const isCollapsed = collapsedSuites.has(suite.name);
@@ -205,7 +257,14 @@ export default function SingleRunView({
               return searchTerms.every((term) => name.includes(term));
             });
 
-          if (filteredTests.length === 0) return null;
+          const fixtures = ["setup", "teardown"].filter((kind) => suite[kind]);
+          const hasSuiteInfo =
+            fixtures.length > 0 || suite.doc || suite.metadata?.length > 0;
+          if (
+            filteredTests.length === 0 &&
+            (searchTerms.length > 0 || !hasSuiteInfo)
+          )
+            return null;
 
           return (
             <div className="suite" key={suite.name} id={`suite-${suite.name}`}>
@@ -221,42 +280,93 @@ export default function SingleRunView({
               </h3>
               {!isCollapsed && (
                 <div className="suite-content">
-                  <table className="diff">
-                    <thead>
-                      <tr>
-                        <th>Test</th>
-                        <th style={{ width: "120px" }}>Status</th>
-                      </tr>
-                    </thead>
-                    <tbody>
-                      {filteredTests.map((test, idx) => (
-                        <tr
-                          key={idx}
-                          className={`test-row ${test.status.toLowerCase()} ${
-                            loadingTest === test.name ? "loading" : ""
-                          } ${activeTestName === test.name ? "selected" : ""}`}
-                          data-test-row={test.name}
-                          onClick={() => handleTestClick(test.name, suite.name)}
-                          style={{ cursor: "pointer" }}
-                          title="Click to view test details"
+                  {hasSuiteInfo && (
+                    <div className="suite-info">
+                      {suite.doc && <p className="suite-doc muted">{suite.doc}</p>}
+                      {suite.metadata?.length > 0 && (
+                        <dl className="suite-metadata">
+                          {suite.metadata.map((meta) => (
+                            <div key={meta.name}>
+                              <dt>{meta.name}</dt>
+                              <dd>{meta.value}</dd>
+                            </div>
+                          ))}
+                        </dl>
+                      )}
+                      {fixtures.map((kind) => (
+                        <button
+                          key={kind}
+                          className={`suite-fixture status-${String(
+                            suite[kind].status || "",
+                          ).toLowerCase()}`}
+                          onClick={() => handleFixtureClick(suite, kind)}
+                          title={suite[kind].statusMessage || "View keywords"}
                         >
-                          <td className="name-cell">
-                            {test.name}
-                            {loadingTest === test.name && (
-                              <span className="loading-spinner"> ⏳</span>
-                            )}
-                          </td>
-                          <td>
-                            <span
-                              className={`status status-${test.status.toLowerCase()}`}
-                            >
-                              {test.status}
-                            </span>
-                          </td>
-                        </tr>
+                          {kind === "setup" ? "Suite Setup" : "Suite Teardown"}:{" "}
+                          {suite[kind].name}
+                          <span
+                            className={`status status-${String(
+                              suite[kind].status || "",
+                            ).toLowerCase()}`}
+                          >
+                            {suite[kind].status}
+                          </span>
+                        </button>
                       ))}
-                    </tbody>
-                  </table>
+                      {suite.source && (
+                        <div className="suite-source muted" title={suite.source}>
+                          {suite.source}
+                        </div>
+                      )}
+                    </div>
+                  )}
+                  {filteredTests.length > 0 && (
+                    <table className="diff">
+                      <thead>
+                        <tr>
+                          <th>Test</th>
+                          <th style={{ width: "120px" }}>Status</th>
+                        </tr>
+                      </thead>
+                      <tbody>
+                        {filteredTests.map((test, idx) => (
+                          <tr
+                            key={idx}
+                            className={`test-row ${test.status.toLowerCase()} ${
+                              loadingTest === test.name ? "loading" : ""
+                            } ${activeTestName === test.name ? "selected" : ""}`}
+                            data-test-row={test.name}
+                            onClick={() => handleTestClick(test.name, suite.name)}
+                            style={{ cursor: "pointer" }}
+                            title="Click to view test details"
+                          >
+                            <td className="name-cell">
+                              {test.name}
+                              {loadingTest === test.name && (
+                                <span className="loading-spinner"> ⏳</span>
+                              )}
+                            </td>
+                            <td>
+                              <span
+                                className={`status status-${test.status.toLowerCase()}`}
+                                title={test.message || undefined}
+                              >
+                                {test.status}
+                              </span>
+                              {test.firstAttempt && (
+                                <span
+                                  className="suite-count muted"
+                                  title="Status of the first attempt before the test was re-executed"
+                                >
+                                  {` (first: ${test.firstAttempt})`}
+                                </span>
+                              )}
+                            </td>
+                          </tr>
+                        ))}
+                      </tbody>
+                    </table>
+                  )}
                 </div>
               )}
             </div>
@@ -9,6 +9,7 @@ export default function TestComparisonModal({
   testName,
   fullName,
   results,
+  columnNames,
   runIds,
   runNames,
   onClose,
@@ -22,15 +23,9 @@ export default function TestComparisonModal({
     return testName;
   }, [fullName, suiteName, testName]);
 
-  const lookupName = useMemo(() => {
-    if (fullName && fullName.includes(".")) {
-      return fullName.split(".").pop() || testName;
-    }
-    if (testName && testName.includes(".")) {
-      return testName.split(".").pop() || testName;
-    }
-    return testName;
-  }, [fullName, testName]);
+  // The long name is looked up as a whole: suite and test names may
+  // contain dots themselves.
+  const lookupName = fullName || testName;
 
   useEffect(() => {
     if (!lookupName || !Array.isArray(runIds) || runIds.length === 0) return;
@@ -53,7 +48,11 @@ export default function TestComparisonModal({
           const res = await fetch(buildApiUrl("/api/test-details"), {
             method: "POST",
             headers: { "Content-Type": "application/json" },
-            body: JSON.stringify({ runId, testName: lookupName }),
+            // Renamed tests are looked up by their name in each run.
+            body: JSON.stringify({
+              runId,
+              testName: columnNames?.[i] || lookupName,
+            }),
           });
           const json = await res.json().catch(() => ({}));
           if (!res.ok) {
@@ -78,7 +77,7 @@ export default function TestComparisonModal({
     return () => {
       cancelled = true;
     };
-  }, [runIds, lookupName, results]);
+  }, [runIds, lookupName, results, columnNames]);
 
   useEffect(() => {
     const handleEsc = (e) => {
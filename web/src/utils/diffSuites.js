@@ -0,0 +1,22 @@
+// flattenDiffSuites turns the nested suites of a diff into a list, each suite
+// before its child suites. Every entry gets a collapse `key`, its dotted
+// `longName` and its `depth` below the root suite.
+export function flattenDiffSuites(suites, depth = 0) {
+  if (!Array.isArray(suites)) return [];
+  return suites.flatMap((suite) => {
+    const path =
+      Array.isArray(suite.path) && suite.path.length > 0
+        ? suite.path
+        : [suite.name];
+    return [
+      {
+        ...suite,
+        tests: suite.tests || [],
+        key: path.join("\u001f"),
+        longName: path.join("."),
+        depth,
+      },
+      ...flattenDiffSuites(suite.suites, depth + 1),
+    ];
+  });
+}